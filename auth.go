@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/abibby/what-it-do/bitbucket"
+	"github.com/abibby/what-it-do/config"
+	"github.com/abibby/what-it-do/ezoauth"
+	"github.com/abibby/what-it-do/github"
+	"github.com/abibby/what-it-do/gitlab"
+	"github.com/abibby/what-it-do/msgraph"
+	"google.golang.org/api/calendar/v3"
+	googleoauth "google.golang.org/api/oauth2/v2"
+	"google.golang.org/api/option"
+)
+
+// authProvider is an oauth login that can be managed with the auth command.
+type authProvider struct {
+	name      string
+	credsFile string
+	config    func() (*ezoauth.Config, error)
+	// identity describes the signed in account.
+	identity func(ctx context.Context, client *http.Client) (string, error)
+}
+
+func (p *authProvider) configured() bool {
+	return config.Exists(p.credsFile)
+}
+
+func authProviders() []*authProvider {
+	providers := []*authProvider{
+		{
+			name:      "jira",
+			credsFile: "atlassian_creds.json",
+			config:    jiraOAuthConfig,
+			identity:  jiraIdentity,
+		},
+		{
+			name:      "bitbucket",
+			credsFile: "bitbucket_creds.json",
+			config:    bitbucketOAuthConfig,
+			identity:  bitbucketIdentity,
+		},
+		{
+			name:      "google",
+			credsFile: "google_creds.json",
+			config:    googleOAuthConfig,
+			identity:  googleIdentity,
+		},
+		{
+			name:      "github",
+			credsFile: "github_creds.json",
+			config:    gitHubOAuthConfig,
+			identity:  gitHubIdentity,
+		},
+		{
+			name:      "gitlab",
+			credsFile: "gitlab_creds.json",
+			config: func() (*ezoauth.Config, error) {
+				glConfig, err := readGitLabConfig()
+				if err != nil {
+					return nil, err
+				}
+				return gitLabOAuthConfig(glConfig.BaseURL)
+			},
+			identity: gitLabIdentity,
+		},
+	}
+
+	// every outlook tenant has its own token
+	tenants := []string{""}
+	calCfg, err := readCalendarConfig()
+	if err == nil {
+		for _, source := range calCfg.Outlook {
+			if source.Tenant != "" {
+				tenants = append(tenants, source.Tenant)
+			}
+		}
+	}
+	for _, tenant := range tenants {
+		name := "microsoft"
+		if tenant != "" {
+			name += "_" + tenant
+		}
+		providers = append(providers, &authProvider{
+			name:      name,
+			credsFile: "microsoft_creds.json",
+			config: func() (*ezoauth.Config, error) {
+				return microsoftOAuthConfig(tenant)
+			},
+			identity: microsoftIdentity,
+		})
+	}
+	return providers
+}
+
+var authCommand = &command{
+	name:  "auth",
+	args:  "<list|login|logout|status|migrate> [provider]",
+	short: "manage the oauth logins for each provider",
+	long: `
+commands:
+  list     list the providers and whether they are configured
+  login    sign in again, all configured providers if none is given
+  logout   delete the saved token, all providers if none is given
+  status   show the saved tokens and the accounts they belong to
+  migrate  move plaintext token files into the token_store from the config
+`,
+	flags: func(fs *flag.FlagSet, g *globalOptions) func(args []string) error {
+		return func(args []string) error {
+			return runAuth(g, args)
+		}
+	},
+}
+
+func runAuth(g *globalOptions, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing auth command, run what-it-do auth -h for help")
+	}
+	command, args := args[0], args[1:]
+	if command == "migrate" {
+		return migrateTokens(g.settings)
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("too many arguments for auth %s", command)
+	}
+
+	providers, err := selectAuthProviders(args)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch command {
+	case "list":
+		return authList(providers)
+	case "login":
+		return authLogin(ctx, providers, len(args) == 0)
+	case "logout":
+		return authLogout(providers)
+	case "status":
+		return authStatus(ctx, providers, len(args) == 0)
+	default:
+		return fmt.Errorf("unknown auth command %q, run what-it-do auth -h for help", command)
+	}
+}
+
+func selectAuthProviders(args []string) ([]*authProvider, error) {
+	providers := authProviders()
+	if len(args) == 0 {
+		return providers, nil
+	}
+	names := []string{}
+	for _, p := range providers {
+		if p.name == args[0] {
+			return []*authProvider{p}, nil
+		}
+		names = append(names, p.name)
+	}
+	return nil, fmt.Errorf("unknown provider %q, must be one of %s", args[0], strings.Join(names, ", "))
+}
+
+func authList(providers []*authProvider) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tCREDS\tLOGGED IN")
+	for _, p := range providers {
+		creds := "missing"
+		loggedIn := "no"
+		if p.configured() {
+			creds = config.Path(p.credsFile)
+			cfg, err := p.config()
+			if err != nil {
+				loggedIn = "error: " + err.Error()
+			} else if _, err := cfg.SavedToken(); err == nil {
+				loggedIn = "yes"
+			} else if !errors.Is(err, ezoauth.ErrTokenNotFound) {
+				loggedIn = "error: " + err.Error()
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", p.name, creds, loggedIn)
+	}
+	return w.Flush()
+}
+
+func authLogin(ctx context.Context, providers []*authProvider, all bool) error {
+	for _, p := range providers {
+		if !p.configured() {
+			if all {
+				continue
+			}
+			return fmt.Errorf("%s is not configured, add %s", p.name, config.Path(p.credsFile))
+		}
+		cfg, err := p.config()
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.name, err)
+		}
+		_, err = cfg.Login(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.name, err)
+		}
+		fmt.Printf("logged in to %s\n", p.name)
+	}
+	return nil
+}
+
+func authLogout(providers []*authProvider) error {
+	for _, p := range providers {
+		// the name is all that is needed to delete the token
+		err := (&ezoauth.Config{Name: p.name}).Logout()
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.name, err)
+		}
+		fmt.Printf("logged out of %s\n", p.name)
+	}
+	return nil
+}
+
+func authStatus(ctx context.Context, providers []*authProvider, all bool) error {
+	printed := false
+	for _, p := range providers {
+		if !p.configured() && all {
+			continue
+		}
+		if printed {
+			fmt.Println()
+		}
+		printed = true
+		fmt.Println(p.name)
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 1, ' ', 0)
+		printAuthStatus(ctx, w, p)
+		err := w.Flush()
+		if err != nil {
+			return err
+		}
+	}
+	if !printed {
+		fmt.Printf("no providers are configured, add creds files to %s\n", config.Dir())
+	}
+	return nil
+}
+
+func printAuthStatus(ctx context.Context, w *tabwriter.Writer, p *authProvider) {
+	if !p.configured() {
+		fmt.Fprintf(w, "  creds:\tmissing %s\n", config.Path(p.credsFile))
+		return
+	}
+	fmt.Fprintf(w, "  creds:\t%s\n", config.Path(p.credsFile))
+
+	cfg, err := p.config()
+	if err != nil {
+		fmt.Fprintf(w, "  error:\t%v\n", err)
+		return
+	}
+	if _, err := cfg.SavedToken(); errors.Is(err, ezoauth.ErrTokenNotFound) {
+		fmt.Fprintf(w, "  status:\tnot logged in, run what-it-do auth login %s\n", p.name)
+		return
+	}
+
+	cfg.NoPrompt = true
+	tok, err := cfg.Token(ctx)
+	if err != nil {
+		fmt.Fprintf(w, "  status:\t%v\n", err)
+		return
+	}
+
+	if tok.Expiry.IsZero() {
+		fmt.Fprintf(w, "  expires:\tnever\n")
+	} else {
+		fmt.Fprintf(w, "  expires:\t%s (in %s)\n", tok.Expiry.Local().Format(time.DateTime), time.Until(tok.Expiry).Round(time.Minute))
+	}
+	if tok.RefreshToken == "" && !tok.Expiry.IsZero() {
+		fmt.Fprintf(w, "  refresh:\tno refresh token, login again when it expires\n")
+	}
+	if scopes := ezoauth.Scopes(tok); len(scopes) > 0 {
+		fmt.Fprintf(w, "  scopes:\t%s\n", strings.Join(scopes, " "))
+	} else if len(cfg.OAuthConfig.Scopes) > 0 {
+		fmt.Fprintf(w, "  scopes:\t%s (requested)\n", strings.Join(cfg.OAuthConfig.Scopes, " "))
+	}
+
+	client, err := cfg.Client(ctx)
+	if err != nil {
+		fmt.Fprintf(w, "  account:\t%v\n", err)
+		return
+	}
+	identity, err := p.identity(ctx, client)
+	if err != nil {
+		fmt.Fprintf(w, "  account:\t%v\n", err)
+		return
+	}
+	fmt.Fprintf(w, "  account:\t%s\n", identity)
+}
+
+func nameAndEmail(name, email string) string {
+	if email == "" {
+		return name
+	}
+	if name == "" {
+		return email
+	}
+	return fmt.Sprintf("%s <%s>", name, email)
+}
+
+func jiraIdentity(ctx context.Context, client *http.Client) (string, error) {
+	jiraClient, err := newJiraClient(client)
+	if err != nil {
+		return "", err
+	}
+	u, _, err := jiraClient.User.GetSelfWithContext(ctx)
+	if err != nil {
+		return "", fmt.Errorf("get self: %w", err)
+	}
+	return nameAndEmail(u.DisplayName, u.EmailAddress), nil
+}
+
+func bitbucketIdentity(ctx context.Context, client *http.Client) (string, error) {
+	u, err := bitbucket.NewClient(client).CurrentUser()
+	if err != nil {
+		return "", err
+	}
+	return u.DisplayName, nil
+}
+
+func googleIdentity(ctx context.Context, client *http.Client) (string, error) {
+	srv, err := googleoauth.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", err
+	}
+	info, err := srv.Userinfo.Get().Context(ctx).Do()
+	if err == nil {
+		return nameAndEmail(info.Name, info.Email), nil
+	}
+
+	// the calendar scope doesn't include userinfo, the primary calendar is
+	// named after the account's email
+	calSrv, calErr := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if calErr != nil {
+		return "", err
+	}
+	primary, calErr := calSrv.CalendarList.Get("primary").Context(ctx).Do()
+	if calErr != nil {
+		return "", err
+	}
+	return primary.Id, nil
+}
+
+func gitHubIdentity(ctx context.Context, client *http.Client) (string, error) {
+	ghConfig, err := readGitHubConfig()
+	if err != nil {
+		return "", err
+	}
+	u, err := github.NewClient(client, ghConfig.BaseURL).CurrentUser()
+	if err != nil {
+		return "", err
+	}
+	return nameAndEmail(u.Login, u.Email), nil
+}
+
+func gitLabIdentity(ctx context.Context, client *http.Client) (string, error) {
+	glConfig, err := readGitLabConfig()
+	if err != nil {
+		return "", err
+	}
+	u, err := gitlab.NewClient(client, glConfig.BaseURL).CurrentUser()
+	if err != nil {
+		return "", err
+	}
+	return nameAndEmail(u.Username, u.Email), nil
+}
+
+func microsoftIdentity(ctx context.Context, client *http.Client) (string, error) {
+	u, err := msgraph.NewClient(client, "").Me()
+	if err != nil {
+		return "", err
+	}
+	email := u.Mail
+	if email == "" {
+		email = u.UserPrincipalName
+	}
+	return nameAndEmail(u.DisplayName, email), nil
+}
@@ -2,9 +2,9 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"regexp"
-	"strings"
+	"os"
 	"time"
 
 	"github.com/abibby/what-it-do/bitbucket"
@@ -13,7 +13,26 @@ import (
 	"golang.org/x/oauth2"
 )
 
-var jiraRE = regexp.MustCompile(`PD-\d+`)
+// bitbucketConfig is the optional bitbucket section of the config.
+type bitbucketConfig struct {
+	// Workspace is the Bitbucket Cloud workspace pull requests are read from.
+	Workspace string `json:"workspace"`
+	// RepositoryCacheTTL is how long the workspace repository list used when
+	// falling back to per repository pull requests is reused, defaults to 24h.
+	RepositoryCacheTTL config.Duration `json:"repository_cache_ttl"`
+}
+
+func readBitbucketConfig() (*bitbucketConfig, error) {
+	cfg := &bitbucketConfig{}
+	err := config.Section("bitbucket", cfg)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if cfg.Workspace == "" {
+		cfg.Workspace = "ownersbox"
+	}
+	return cfg, nil
+}
 
 func getCodeReviews(start, end time.Time) ([]*Row, error) {
 	bbCient, err := getBitbucketService()
@@ -25,12 +44,18 @@ func getCodeReviews(start, end time.Time) ([]*Row, error) {
 		return nil, err
 	}
 
+	bbConfig, err := readBitbucketConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	rows := []*Row{}
 
-	prs, err := bbCient.ListWorkspacePullRequests(&bitbucket.ListWorkspacePullRequestsOptions{
-		Workspace: "ownersbox",
-		Fields:    "+reviewers",
-		Query:     fmt.Sprintf(`(state="MERGED" or state="OPEN") and followers.uuid="%s" and updated_on > %s AND updated_on < %s`, u.UUID, start.Format(time.RFC3339), end.Format(time.RFC3339)),
+	prs, err := bbCient.ListFollowedPullRequests(&bitbucket.ListFollowedPullRequestsOptions{
+		Workspace: bbConfig.Workspace,
+		User:      u,
+		Start:     start,
+		End:       end,
 	})
 	if err != nil {
 		return nil, err
@@ -42,12 +67,7 @@ func getCodeReviews(start, end time.Time) ([]*Row, error) {
 			continue
 		}
 
-		description := pr.Title
-		jiraID := jiraRE.FindString(description)
-		if jiraID != "" {
-			description = regexp.MustCompile(".*"+jiraID+":?").ReplaceAllString(description, "")
-		}
-		description = strings.TrimSpace(description)
+		jiraID, description := splitJiraID(pr.Title)
 		rows = append(rows, &Row{
 			Date:        start,
 			Project:     "Technical - ",
@@ -56,6 +76,9 @@ func getCodeReviews(start, end time.Time) ([]*Row, error) {
 			Description: description,
 		})
 	}
+	if err := prs.AllError(); err != nil {
+		return nil, err
+	}
 
 	return rows, nil
 }
@@ -76,24 +99,77 @@ func didReview(pr *bitbucket.PullRequest, user *bitbucket.Account, start, end ti
 	return false
 }
 
-func getBitbucketService() (*bitbucket.Client, error) {
+// bitbucketServerConfig is the bitbucket_server section of the config. When
+// it is set code reviews are fetched from Bitbucket Data Center instead of
+// Bitbucket Cloud.
+type bitbucketServerConfig struct {
+	BaseURL string `json:"base_url"`
+	// Token is a Data Center personal access token.
+	Token string `json:"token"`
+}
+
+func getBitbucketService() (bitbucket.Service, error) {
+	serverConfig := &bitbucketServerConfig{}
+	err := config.Section("bitbucket_server", serverConfig)
+	if err == nil {
+		return getBitbucketServerService(serverConfig)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return getBitbucketCloudService()
+}
+
+func getBitbucketServerService(cfg *bitbucketServerConfig) (*bitbucket.ServerClient, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("bitbucket_server: base_url is required")
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("bitbucket_server: token is required")
+	}
 	ctx := context.Background()
-	config, err := ezoauth.ReadConfigJSON(config.Dir("bitbucket_creds.json"))
+	client := oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{
+		AccessToken: cfg.Token,
+		TokenType:   "Bearer",
+	}))
+	client.Transport = ezoauth.NewLogRoundTripper("bitbucket-server", client.Transport)
+
+	return bitbucket.NewServerClient(client, cfg.BaseURL), nil
+}
+
+func bitbucketOAuthConfig() (*ezoauth.Config, error) {
+	oauthConfig, err := ezoauth.ReadConfigJSON(config.Path("bitbucket_creds.json"))
 	if err != nil {
 		return nil, err
 	}
-	config.Endpoint = oauth2.Endpoint{
+	oauthConfig.Endpoint = oauth2.Endpoint{
 		AuthURL:  "https://bitbucket.org/site/oauth2/authorize",
 		TokenURL: "https://bitbucket.org/site/oauth2/access_token",
 	}
-	ezconfig := &ezoauth.Config{
+	return &ezoauth.Config{
 		Name:        "bitbucket",
-		OAuthConfig: config,
+		OAuthConfig: oauthConfig,
+		// Bitbucket Cloud doesn't support PKCE
+		DisablePKCE: true,
+	}, nil
+}
+
+func getBitbucketCloudService() (*bitbucket.Client, error) {
+	ctx := context.Background()
+	ezconfig, err := bitbucketOAuthConfig()
+	if err != nil {
+		return nil, err
 	}
 	client, err := ezconfig.Client(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("could not start bitbucket client: %w", err)
 	}
 
-	return bitbucket.NewClient(client), nil
+	bbConfig, err := readBitbucketConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	bbClient := bitbucket.NewClient(client)
+	bbClient.SetRepositoryCache(config.CacheDir("bitbucket_repositories.json"), bbConfig.RepositoryCacheTTL.Or(24*time.Hour))
+	return bbClient, nil
 }
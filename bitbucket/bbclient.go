@@ -1,20 +1,29 @@
 package bitbucket
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"iter"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"reflect"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/abibby/what-it-do/jsonio"
+	"github.com/abibby/what-it-do/parallel"
 )
 
 type Client struct {
 	httpClient      *http.Client
 	baseURL         string
 	internalBaseURL string
+
+	repoCache *repositoryCache
 }
 
 func NewClient(c *http.Client) *Client {
@@ -22,6 +31,7 @@ func NewClient(c *http.Client) *Client {
 		httpClient:      c,
 		baseURL:         "https://api.bitbucket.org",
 		internalBaseURL: "https://bitbucket.org",
+		repoCache:       newRepositoryCache(),
 	}
 }
 
@@ -94,12 +104,16 @@ func (c *Client) internalRequest(method, p string, query, body any, v any) error
 }
 
 func (c *Client) rawRequest(method, url string, body io.Reader, v any) error {
+	return rawRequest(c.httpClient, method, url, body, v)
+}
+
+func rawRequest(httpClient *http.Client, method, url string, body io.Reader, v any) error {
 	req, err := http.NewRequest(method, url, body)
 	if err != nil {
 		return err
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -143,9 +157,9 @@ func toValues(v any) url.Values {
 		if (fv == reflect.Value{} || (fv.IsZero())) {
 			continue
 		}
-		if rv.Kind() == reflect.Array {
-			for j := 0; j < rv.Len(); j++ {
-				val.Add(q, fmt.Sprint(fv.Index(i).Interface()))
+		if fv.Kind() == reflect.Array || fv.Kind() == reflect.Slice {
+			for j := 0; j < fv.Len(); j++ {
+				val.Add(q, fmt.Sprint(fv.Index(j).Interface()))
 			}
 		} else {
 			val.Add(q, fmt.Sprint(fv.Interface()))
@@ -170,3 +184,86 @@ func (c *Client) ListWorkspacePullRequests(options *ListWorkspacePullRequestsOpt
 }
 
 // https://bitbucket.org/!api/internal/workspaces/ownersbox/pullrequests/?fields=-values.closed_by%2C-values.description%2C-values.summary%2C-values.rendered%2C-values.properties%2C-values.reason%2C-values.reviewers%2C-values.participants.user.nickname%2C%2Bvalues.destination.branch.name%2C%2Bvalues.destination.repository.full_name%2C%2Bvalues.destination.repository.name%2C%2Bvalues.destination.repository.uuid%2C%2Bvalues.destination.repository.full_name%2C%2Bvalues.destination.repository.name%2C%2Bvalues.destination.repository.links.self.href%2C%2Bvalues.destination.repository.links.html.href%2C%2Bvalues.source.branch.name%2C%2Bvalues.source.repository.full_name%2C%2Bvalues.source.repository.name%2C%2Bvalues.source.repository.uuid%2C%2Bvalues.source.repository.full_name%2C%2Bvalues.source.repository.name%2C%2Bvalues.source.repository.links.self.href%2C%2Bvalues.source.repository.links.html.href%2C%2Bvalues.source.commit.hash&page=1&pagelen=20&q=state%3D%22OPEN%22%20AND%20followers.uuid%3D%22c8c46d4c-e199-4859-a510-e038ef88d80e%22
+
+// ListFollowedPullRequests returns the open and merged pull requests followed
+// by the user that were updated between start and end.
+//
+// The workspace pull request endpoint is internal to Bitbucket and may change
+// without notice. If it fails the pull requests of every repository in the
+// workspace are fetched through the public API instead.
+func (c *Client) ListFollowedPullRequests(options *ListFollowedPullRequestsOptions) (Pager[*PullRequest], error) {
+	prs, err := c.ListWorkspacePullRequests(&ListWorkspacePullRequestsOptions{
+		Workspace: options.Workspace,
+		Fields:    "+reviewers",
+		Query:     fmt.Sprintf(`(state="MERGED" or state="OPEN") and followers.uuid="%s" and updated_on > %s AND updated_on < %s`, options.User.UUID, options.Start.Format(time.RFC3339), options.End.Format(time.RFC3339)),
+	})
+	if err == nil {
+		return prs, nil
+	}
+	slog.Warn("internal workspace pull request endpoint failed, falling back to repository pull requests", "workspace", options.Workspace, "err", err)
+	return c.listRepositoryPullRequests(options)
+}
+
+// repositoryPullRequestConcurrency limits the number of repositories queried
+// at once by the pull request fallback.
+const repositoryPullRequestConcurrency = 8
+
+func (c *Client) listRepositoryPullRequests(options *ListFollowedPullRequestsOptions) (Pager[*PullRequest], error) {
+	repos, err := c.cachedRepositories(options.Workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`(state="MERGED" or state="OPEN") and updated_on > %s AND updated_on < %s`, options.Start.Format(time.RFC3339), options.End.Format(time.RFC3339))
+
+	mtx := &sync.Mutex{}
+	sem := make(chan struct{}, repositoryPullRequestConcurrency)
+	result := slicePager[*PullRequest]{}
+
+	err = parallel.Seq(context.Background(), slices.Values(repos), func(ctx context.Context, repo *Repository) error {
+		sem <- struct{}{}
+		defer func() { <-sem }()
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		prs, err := c.ListPullRequests(&ListPullRequestsOptions{
+			Workspace: options.Workspace,
+			Slug:      repo.Slug,
+			Fields:    "+values.participants,+values.reviewers",
+			// the endpoint only returns open pull requests without state
+			State: []string{"OPEN", "MERGED"},
+			Query: query,
+		})
+		if err != nil {
+			return fmt.Errorf("list pull requests for %s: %w", repo.FullName, err)
+		}
+
+		found := []*PullRequest{}
+		for pr := range prs.All() {
+			found = append(found, pr)
+		}
+		if err := prs.AllError(); err != nil {
+			return fmt.Errorf("list pull requests for %s: %w", repo.FullName, err)
+		}
+
+		mtx.Lock()
+		defer mtx.Unlock()
+		result = append(result, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+type slicePager[T any] []T
+
+func (p slicePager[T]) All() iter.Seq[T] {
+	return slices.Values(p)
+}
+func (p slicePager[T]) AllError() error {
+	return nil
+}
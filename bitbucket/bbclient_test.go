@@ -0,0 +1,108 @@
+package bitbucket
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client that sends both public and internal api
+// requests to mux.
+func newTestClient(t *testing.T, mux *http.ServeMux) (*Client, *httptest.Server) {
+	t.Helper()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	c := NewClient(srv.Client())
+	c.baseURL = srv.URL
+	c.internalBaseURL = srv.URL
+	return c, srv
+}
+
+func TestPaginatedResponseAll(t *testing.T) {
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("GET /2.0/repositories/example", func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		switch page {
+		case "":
+			fmt.Fprintf(w, `{"size":3,"page":1,"pagelen":2,"next":"%s/2.0/repositories/example?page=2","values":[{"slug":"app"},{"slug":"api"}]}`, srv.URL)
+		case "2":
+			fmt.Fprint(w, `{"size":3,"page":2,"pagelen":2,"values":[{"slug":"web"}]}`)
+		default:
+			t.Errorf("unexpected page %q", page)
+			http.NotFound(w, r)
+		}
+	})
+	c, srv := newTestClient(t, mux)
+
+	repos, err := c.cachedRepositories("example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slugs := []string{}
+	for _, repo := range repos {
+		slugs = append(slugs, repo.Slug)
+	}
+	if want := []string{"app", "api", "web"}; !slices.Equal(slugs, want) {
+		t.Errorf("expected %v, got %v", want, slugs)
+	}
+}
+
+func TestListFollowedPullRequestsFallback(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /!api/internal/workspaces/example/pullrequests/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("GET /2.0/repositories/example", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"size":2,"page":1,"pagelen":10,"values":[{"slug":"app","full_name":"example/app"},{"slug":"api","full_name":"example/api"}]}`)
+	})
+	mux.HandleFunc("GET /2.0/repositories/example/{repo}/pullrequests", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if state := q["state"]; !slices.Equal(state, []string{"OPEN", "MERGED"}) {
+			t.Errorf("expected the open and merged states, got %q", state)
+		}
+		if want := `(state="MERGED" or state="OPEN") and updated_on > 2024-11-12T00:00:00Z AND updated_on < 2024-11-12T23:59:59Z`; q.Get("q") != want {
+			t.Errorf("expected q %s, got %s", want, q.Get("q"))
+		}
+		switch r.PathValue("repo") {
+		case "app":
+			fmt.Fprint(w, `{"size":1,"page":1,"pagelen":10,"values":[{"id":1,"title":"Open","state":"OPEN"}]}`)
+		case "api":
+			fmt.Fprint(w, `{"size":1,"page":1,"pagelen":10,"values":[{"id":2,"title":"Merged","state":"MERGED"}]}`)
+		}
+	})
+	c, _ := newTestClient(t, mux)
+
+	start := time.Date(2024, time.November, 12, 0, 0, 0, 0, time.UTC)
+	prs, err := c.ListFollowedPullRequests(&ListFollowedPullRequestsOptions{
+		Workspace: "example",
+		User:      &Account{UUID: "{me}"},
+		Start:     start,
+		End:       start.Add(24*time.Hour - time.Second),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := []string{}
+	for pr := range prs.All() {
+		titles = append(titles, pr.Title)
+	}
+	slices.Sort(titles)
+	if want := []string{"Merged", "Open"}; !slices.Equal(titles, want) {
+		t.Errorf("expected %v, got %v", want, titles)
+	}
+}
+
+func TestToValues(t *testing.T) {
+	got := toValues(&ListPullRequestsOptions{
+		Workspace: "example",
+		Fields:    "+values.participants",
+		State:     []string{"OPEN", "MERGED"},
+	}).Encode()
+	if want := "fields=%2Bvalues.participants&state=OPEN&state=MERGED"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
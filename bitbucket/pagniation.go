@@ -33,8 +33,9 @@ func (r *PaginatedResponse[T]) All() iter.Seq[T] {
 			if page.Size < page.PageLen*page.Page {
 				return
 			}
+			next := page.Next
 			page = &PaginatedResponse[T]{}
-			err := r.client.rawRequest(http.MethodGet, page.Next, http.NoBody, page)
+			err := r.client.rawRequest(http.MethodGet, next, http.NoBody, page)
 			if err != nil {
 				r.iterErr = err
 				return
@@ -0,0 +1,117 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+// repositoryCache holds the repository list of each workspace used by the
+// pull request fallback. Entries are kept in memory and, if path is set,
+// persisted between runs.
+type repositoryCache struct {
+	mtx     sync.Mutex
+	path    string
+	ttl     time.Duration
+	entries map[string]*repositoryCacheEntry
+}
+
+func newRepositoryCache() *repositoryCache {
+	return &repositoryCache{
+		ttl: time.Hour,
+	}
+}
+
+type repositoryCacheEntry struct {
+	FetchedAt    time.Time     `json:"fetched_at"`
+	Repositories []*Repository `json:"repositories"`
+}
+
+// SetRepositoryCache persists the repository list fetched by the pull request
+// fallback to file and reuses it until it is older than ttl.
+func (c *Client) SetRepositoryCache(file string, ttl time.Duration) {
+	c.repoCache.mtx.Lock()
+	defer c.repoCache.mtx.Unlock()
+	c.repoCache.path = file
+	c.repoCache.ttl = ttl
+	c.repoCache.entries = nil
+}
+
+func (c *Client) cachedRepositories(workspace string) ([]*Repository, error) {
+	cache := c.repoCache
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+
+	if cache.entries == nil {
+		cache.entries = cache.read()
+	}
+	if entry, ok := cache.entries[workspace]; ok && time.Since(entry.FetchedAt) < cache.ttl {
+		return entry.Repositories, nil
+	}
+
+	resp, err := c.ListRepositories(&ListRepositoriesOptions{
+		Workspace: workspace,
+		Role:      "member",
+		Fields:    "values.uuid,values.name,values.slug,values.full_name,values.updated_on,next,page,pagelen,size",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list repositories: %w", err)
+	}
+	repos := []*Repository{}
+	for repo := range resp.All() {
+		repos = append(repos, repo)
+	}
+	if err := resp.AllError(); err != nil {
+		return nil, fmt.Errorf("list repositories: %w", err)
+	}
+
+	cache.entries[workspace] = &repositoryCacheEntry{
+		FetchedAt:    time.Now(),
+		Repositories: repos,
+	}
+	err = cache.write(cache.entries)
+	if err != nil {
+		slog.Warn("failed to save bitbucket repository cache", "err", err)
+	}
+	return repos, nil
+}
+
+func (cache *repositoryCache) read() map[string]*repositoryCacheEntry {
+	entries := map[string]*repositoryCacheEntry{}
+	if cache.path == "" {
+		return entries
+	}
+	b, err := os.ReadFile(cache.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries
+	} else if err != nil {
+		slog.Warn("failed to read bitbucket repository cache", "err", err)
+		return entries
+	}
+	err = json.Unmarshal(b, &entries)
+	if err != nil {
+		slog.Warn("failed to parse bitbucket repository cache", "err", err)
+		return map[string]*repositoryCacheEntry{}
+	}
+	return entries
+}
+
+func (cache *repositoryCache) write(entries map[string]*repositoryCacheEntry) error {
+	if cache.path == "" {
+		return nil
+	}
+	err := os.MkdirAll(path.Dir(cache.path), 0755)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cache.path, b, 0644)
+}
@@ -0,0 +1,211 @@
+package bitbucket
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ServerClient is a client for Bitbucket Data Center (formerly Bitbucket
+// Server). The http client is expected to add a personal access token as a
+// bearer token.
+type ServerClient struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+func NewServerClient(c *http.Client, baseURL string) *ServerClient {
+	return &ServerClient{
+		httpClient: c,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+func (c *ServerClient) CurrentUser() (*Account, error) {
+	u, err := c.CurrentServerUser()
+	if err != nil {
+		return nil, err
+	}
+	return u.toAccount(), nil
+}
+
+// CurrentServerUser returns the user the access token belongs to. Data Center
+// has no REST endpoint for this so the username is fetched from the applinks
+// whoami servlet.
+func (c *ServerClient) CurrentServerUser() (*ServerUser, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/plugins/servlet/applinks/whoami", http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("fetch error: %s", b)
+	}
+	username := strings.TrimSpace(string(b))
+	if username == "" {
+		return nil, fmt.Errorf("fetch error: not authenticated")
+	}
+
+	u := &ServerUser{}
+	err = c.request(http.MethodGet, "/rest/api/1.0/users/"+url.PathEscape(username), nil, u)
+	return u, err
+}
+
+type ListDashboardPullRequestsOptions struct {
+	State       string `query:"state"`
+	Role        string `query:"role"`
+	Order       string `query:"order"`
+	ClosedSince int64  `query:"closedSince"`
+}
+
+func (c *ServerClient) ListDashboardPullRequests(options *ListDashboardPullRequestsOptions) (*ServerPaginatedResponse[*ServerPullRequest], error) {
+	return serverList[*ServerPullRequest](c, "/rest/api/1.0/dashboard/pull-requests", toValues(options))
+}
+
+type ListPullRequestActivitiesOptions struct {
+	ProjectKey string
+	Slug       string
+	ID         int
+}
+
+func (c *ServerClient) ListPullRequestActivities(options *ListPullRequestActivitiesOptions) (*ServerPaginatedResponse[*ServerActivity], error) {
+	p := fmt.Sprintf(
+		"/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d/activities",
+		url.PathEscape(options.ProjectKey),
+		url.PathEscape(options.Slug),
+		options.ID,
+	)
+	return serverList[*ServerActivity](c, p, url.Values{})
+}
+
+// ListFollowedPullRequests returns the open and merged pull requests the user
+// is involved in that were updated between start and end. The activity of
+// each pull request is fetched to fill in Participant.ParticipatedOn.
+func (c *ServerClient) ListFollowedPullRequests(options *ListFollowedPullRequestsOptions) (Pager[*PullRequest], error) {
+	open, err := c.ListDashboardPullRequests(&ListDashboardPullRequestsOptions{
+		State: "OPEN",
+	})
+	if err != nil {
+		return nil, err
+	}
+	merged, err := c.ListDashboardPullRequests(&ListDashboardPullRequestsOptions{
+		State:       "MERGED",
+		ClosedSince: options.Start.UnixMilli(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &serverFollowedPager{
+		client:  c,
+		pages:   []*ServerPaginatedResponse[*ServerPullRequest]{open, merged},
+		options: options,
+	}, nil
+}
+
+type serverFollowedPager struct {
+	client  *ServerClient
+	pages   []*ServerPaginatedResponse[*ServerPullRequest]
+	options *ListFollowedPullRequestsOptions
+	iterErr error
+}
+
+func (p *serverFollowedPager) All() iter.Seq[*PullRequest] {
+	return func(yield func(*PullRequest) bool) {
+		for _, page := range p.pages {
+			for pr := range page.All() {
+				updated := time.UnixMilli(pr.UpdatedDate)
+				if updated.Before(p.options.Start) || updated.After(p.options.End) {
+					continue
+				}
+
+				participatedOn, err := p.client.participatedOn(pr, p.options.Start)
+				if err != nil {
+					p.iterErr = err
+					return
+				}
+				if !yield(pr.toPullRequest(participatedOn)) {
+					return
+				}
+			}
+			if err := page.AllError(); err != nil {
+				p.iterErr = err
+				return
+			}
+		}
+	}
+}
+
+func (p *serverFollowedPager) AllError() error {
+	return p.iterErr
+}
+
+// participatedOn returns the time of each user's most recent review activity
+// on the pull request. Activities are returned newest first so paging stops
+// once they are older than since.
+func (c *ServerClient) participatedOn(pr *ServerPullRequest, since time.Time) (map[int]time.Time, error) {
+	result := map[int]time.Time{}
+	if pr.ToRef == nil || pr.ToRef.Repository == nil || pr.ToRef.Repository.Project == nil {
+		return result, nil
+	}
+
+	activities, err := c.ListPullRequestActivities(&ListPullRequestActivitiesOptions{
+		ProjectKey: pr.ToRef.Repository.Project.Key,
+		Slug:       pr.ToRef.Repository.Slug,
+		ID:         pr.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for activity := range activities.All() {
+		created := activity.Created()
+		if created.Before(since) {
+			break
+		}
+		if activity.User == nil {
+			continue
+		}
+		switch activity.Action {
+		case "APPROVED", "UNAPPROVED", "REVIEWED", "COMMENTED":
+		default:
+			continue
+		}
+		if last, ok := result[activity.User.ID]; !ok || created.After(last) {
+			result[activity.User.ID] = created
+		}
+	}
+
+	return result, activities.AllError()
+}
+
+func serverList[T any](c *ServerClient, p string, query url.Values) (*ServerPaginatedResponse[T], error) {
+	u := &ServerPaginatedResponse[T]{
+		client: c,
+		path:   p,
+		query:  query,
+	}
+	err := c.rawRequest(http.MethodGet, c.baseURL+p+"?"+query.Encode(), http.NoBody, u)
+	return u, err
+}
+
+func (c *ServerClient) request(method, p string, query any, v any) error {
+	queryValues := toValues(query)
+	return c.rawRequest(method, c.baseURL+p+"?"+queryValues.Encode(), http.NoBody, v)
+}
+
+func (c *ServerClient) rawRequest(method, url string, body io.Reader, v any) error {
+	return rawRequest(c.httpClient, method, url, body, v)
+}
@@ -0,0 +1,54 @@
+package bitbucket
+
+import (
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type ServerPaginatedResponse[T any] struct {
+	Size          int  `json:"size"`
+	Limit         int  `json:"limit"`
+	IsLastPage    bool `json:"isLastPage"`
+	Start         int  `json:"start"`
+	NextPageStart int  `json:"nextPageStart"`
+	Values        []T  `json:"values"`
+
+	client  *ServerClient
+	path    string
+	query   url.Values
+	iterErr error
+}
+
+func (r *ServerPaginatedResponse[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		page := r
+		for {
+			for _, v := range page.Values {
+				if !yield(v) {
+					return
+				}
+			}
+			if page.IsLastPage {
+				return
+			}
+
+			query := url.Values{}
+			for k, v := range r.query {
+				query[k] = v
+			}
+			query.Set("start", strconv.Itoa(page.NextPageStart))
+
+			page = &ServerPaginatedResponse[T]{}
+			err := r.client.rawRequest(http.MethodGet, r.client.baseURL+r.path+"?"+query.Encode(), http.NoBody, page)
+			if err != nil {
+				r.iterErr = err
+				return
+			}
+		}
+	}
+}
+func (r *ServerPaginatedResponse[T]) AllError() error {
+	return r.iterErr
+}
@@ -0,0 +1,115 @@
+package bitbucket
+
+import (
+	"strconv"
+	"time"
+)
+
+type ServerUser struct {
+	Name         string `json:"name"`
+	EmailAddress string `json:"emailAddress"`
+	ID           int    `json:"id"`
+	DisplayName  string `json:"displayName"`
+	Slug         string `json:"slug"`
+	Type         string `json:"type"`
+}
+
+func (u *ServerUser) toAccount() *Account {
+	if u == nil {
+		return nil
+	}
+	return &Account{
+		Type:        "user",
+		DisplayName: u.DisplayName,
+		UUID:        strconv.Itoa(u.ID),
+	}
+}
+
+type ServerParticipant struct {
+	User     *ServerUser `json:"user"`
+	Role     string      `json:"role"`
+	Approved bool        `json:"approved"`
+	Status   string      `json:"status"`
+}
+
+type ServerProject struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+type ServerRepository struct {
+	Slug    string         `json:"slug"`
+	Name    string         `json:"name"`
+	Project *ServerProject `json:"project"`
+}
+
+type ServerRef struct {
+	ID         string            `json:"id"`
+	DisplayID  string            `json:"displayId"`
+	Repository *ServerRepository `json:"repository"`
+}
+
+type ServerPullRequest struct {
+	ID           int                  `json:"id"`
+	Title        string               `json:"title"`
+	Description  string               `json:"description"`
+	State        string               `json:"state"`
+	Author       *ServerParticipant   `json:"author"`
+	Reviewers    []*ServerParticipant `json:"reviewers"`
+	Participants []*ServerParticipant `json:"participants"`
+	FromRef      *ServerRef           `json:"fromRef"`
+	ToRef        *ServerRef           `json:"toRef"`
+	// CreatedDate and UpdatedDate are unix timestamps in milliseconds.
+	CreatedDate int64 `json:"createdDate"`
+	UpdatedDate int64 `json:"updatedDate"`
+}
+
+// toPullRequest converts a Data Center pull request to the Cloud shape.
+// participatedOn maps user ids to the time of their last review activity,
+// Data Center doesn't include it on the participant.
+func (pr *ServerPullRequest) toPullRequest(participatedOn map[int]time.Time) *PullRequest {
+	result := &PullRequest{
+		ID:    pr.ID,
+		Title: pr.Title,
+		State: pr.State,
+	}
+	if pr.Author != nil {
+		result.Author = pr.Author.User.toAccount()
+	}
+
+	seen := map[int]bool{}
+	for _, p := range append(append([]*ServerParticipant{}, pr.Reviewers...), pr.Participants...) {
+		if p.User == nil || seen[p.User.ID] {
+			continue
+		}
+		seen[p.User.ID] = true
+
+		if p.Role == "REVIEWER" {
+			result.Reviewers = append(result.Reviewers, p.User.toAccount())
+		}
+
+		participant := &Participant{
+			User:     p.User.toAccount(),
+			Role:     p.Role,
+			Approved: p.Approved,
+			State:    p.Status,
+		}
+		if t, ok := participatedOn[p.User.ID]; ok {
+			participant.ParticipatedOn = t.Format(time.RFC3339)
+		}
+		result.Participants = append(result.Participants, participant)
+	}
+	return result
+}
+
+type ServerActivity struct {
+	ID     int         `json:"id"`
+	Action string      `json:"action"`
+	User   *ServerUser `json:"user"`
+	// CreatedDate is a unix timestamp in milliseconds.
+	CreatedDate int64 `json:"createdDate"`
+}
+
+func (a *ServerActivity) Created() time.Time {
+	return time.UnixMilli(a.CreatedDate)
+}
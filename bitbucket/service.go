@@ -0,0 +1,30 @@
+package bitbucket
+
+import (
+	"iter"
+	"time"
+)
+
+// Service is the subset of the Bitbucket API used to find code reviews. It is
+// implemented by Client for Bitbucket Cloud and ServerClient for Bitbucket
+// Data Center.
+type Service interface {
+	CurrentUser() (*Account, error)
+	ListFollowedPullRequests(options *ListFollowedPullRequestsOptions) (Pager[*PullRequest], error)
+}
+
+var _ Service = (*Client)(nil)
+var _ Service = (*ServerClient)(nil)
+
+type Pager[T any] interface {
+	All() iter.Seq[T]
+	AllError() error
+}
+
+type ListFollowedPullRequestsOptions struct {
+	// Workspace is only used by Bitbucket Cloud.
+	Workspace string
+	User      *Account
+	Start     time.Time
+	End       time.Time
+}
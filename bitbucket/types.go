@@ -107,6 +107,7 @@ type Repository struct {
 	Links       *RepositoryLinks `json:"links"`
 	UUID        string           `json:"uuid"`
 	FullName    string           `json:"full_name"`
+	Slug        string           `json:"slug"`
 	IsPrivate   bool             `json:"is_private"`
 	Parent      *Repository      `json:"parent"`
 	Scm         string           `json:"scm"`
@@ -0,0 +1,119 @@
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abibby/what-it-do/ical"
+)
+
+// Client queries a single CalDAV calendar collection e.g.
+// https://cloud.example.com/remote.php/dav/calendars/me/personal/
+type Client struct {
+	httpClient  *http.Client
+	calendarURL string
+	username    string
+	password    string
+}
+
+func NewClient(c *http.Client, calendarURL string) *Client {
+	return &Client{
+		httpClient:  c,
+		calendarURL: calendarURL,
+	}
+}
+
+const calendarQuery = `<?xml version="1.0" encoding="utf-8" ?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop>
+    <C:calendar-data/>
+  </D:prop>
+  <C:filter>
+    <C:comp-filter name="VCALENDAR">
+      <C:comp-filter name="VEVENT">
+        <C:time-range start="%s" end="%s"/>
+      </C:comp-filter>
+    </C:comp-filter>
+  </C:filter>
+</C:calendar-query>`
+
+type multistatus struct {
+	Responses []*response `xml:"DAV: response"`
+}
+
+type response struct {
+	Href     string      `xml:"DAV: href"`
+	Propstat []*propstat `xml:"DAV: propstat"`
+}
+
+type propstat struct {
+	Status string `xml:"DAV: status"`
+	Prop   *prop  `xml:"DAV: prop"`
+}
+
+type prop struct {
+	CalendarData string `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+}
+
+// SetBasicAuth authenticates requests with a username and password, for
+// Nextcloud this should be an app password.
+func (c *Client) SetBasicAuth(username, password string) {
+	c.username = username
+	c.password = password
+}
+
+// Events returns the events with an instance between start and end.
+// Recurring events are returned as their master event and need to be
+// expanded with ical.Expand.
+func (c *Client) Events(start, end time.Time, loc *time.Location) ([]*ical.Event, error) {
+	body := fmt.Sprintf(calendarQuery, start.UTC().Format("20060102T150405Z"), end.UTC().Format("20060102T150405Z"))
+	req, err := http.NewRequest("REPORT", c.calendarURL, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	req.Header.Set("Depth", "1")
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("caldav request failed: %s: %s", resp.Status, b)
+	}
+
+	ms := &multistatus{}
+	err = xml.Unmarshal(b, ms)
+	if err != nil {
+		return nil, fmt.Errorf("invalid caldav response: %w", err)
+	}
+
+	events := []*ical.Event{}
+	for _, r := range ms.Responses {
+		for _, ps := range r.Propstat {
+			if ps.Prop == nil || ps.Prop.CalendarData == "" || !strings.Contains(ps.Status, " 200 ") {
+				continue
+			}
+			resourceEvents, err := ical.ParseEvents(bytes.NewBufferString(ps.Prop.CalendarData), loc)
+			if err != nil {
+				return nil, fmt.Errorf("invalid calendar data in %s: %w", r.Href, err)
+			}
+			events = append(events, resourceEvents...)
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/abibby/what-it-do/config"
+	"github.com/abibby/what-it-do/httpcache"
+)
+
+var cacheCommand = &command{
+	name:  "cache",
+	args:  "<stats|clear>",
+	short: "inspect or clear the cached api responses",
+	long: `
+commands:
+  stats  show the size and age of each cache
+  clear  delete every cache
+
+Reports for days that are over reuse the searches for that day until the
+cache is cleared, every other api response is reused for http_cache_ttl from
+the config. Run with -offline to only use cached responses.
+`,
+	flags: func(fs *flag.FlagSet, g *globalOptions) func(args []string) error {
+		return func(args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expected one cache command, run what-it-do cache -h for help")
+			}
+			cache := httpcache.New(config.HTTPCacheDir())
+			switch args[0] {
+			case "stats":
+				return cacheStats(cache)
+			case "clear":
+				return cacheClear(cache)
+			default:
+				return fmt.Errorf("unknown cache command %q, run what-it-do cache -h for help", args[0])
+			}
+		}
+	},
+}
+
+// cacheFiles are the caches written to the cache directory.
+var cacheFiles = []string{
+	"bitbucket_repositories.json",
+}
+
+func cacheStats(cache *httpcache.Cache) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CACHE\tENTRIES\tSIZE\tUPDATED")
+
+	stats, err := cache.Stats()
+	if err != nil {
+		return err
+	}
+	updated := "-"
+	if !stats.Newest.IsZero() {
+		updated = since(stats.Newest)
+	}
+	fmt.Fprintf(w, "http responses\t%d (%d expired)\t%d B\t%s\n", stats.Entries, stats.Expired, stats.Size, updated)
+
+	for _, name := range cacheFiles {
+		info, err := os.Stat(config.CacheDir(name))
+		if errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(w, "%s\t-\t-\t-\n", name)
+			continue
+		} else if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t-\t%d B\t%s\n", name, info.Size(), since(info.ModTime()))
+	}
+	return w.Flush()
+}
+
+func since(t time.Time) string {
+	return time.Since(t).Round(time.Second).String() + " ago"
+}
+
+func cacheClear(cache *httpcache.Cache) error {
+	err := cache.Clear()
+	if err != nil {
+		return err
+	}
+	for _, name := range cacheFiles {
+		err := os.Remove(config.CacheDir(name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	fmt.Println("cleared the cache")
+	return nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/abibby/what-it-do/config"
+)
+
+var configCommand = &command{
+	name:  "config",
+	args:  "<dir|paths|show|profiles|validate|migrate>",
+	short: "show, validate and migrate the config",
+	long: `
+commands:
+  dir       print the config directory
+  paths     print where the config, tokens and caches are kept
+  show      print the selected profile with env overrides applied
+  profiles  list the profiles in config.yaml
+  validate  check the selected profile for mistakes
+  migrate   convert the per source json files into config.yaml
+
+Values can be overridden with WHAT_IT_DO_* environment variables, nested keys
+are separated by a double underscore, e.g. WHAT_IT_DO_TIME_ZONE=UTC or
+WHAT_IT_DO_CALENDAR__OVERLAP_POLICY=first-wins.
+`,
+	flags: func(fs *flag.FlagSet, g *globalOptions) func(args []string) error {
+		return func(args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expected one config command, run what-it-do config -h for help")
+			}
+			switch args[0] {
+			case "dir":
+				fmt.Println(config.Dir())
+				return nil
+			case "paths":
+				return printPaths(g)
+			case "show":
+				b, err := config.Marshal()
+				if err != nil {
+					return err
+				}
+				fmt.Printf("# profile %s from %s\n%s", config.Profile(), config.Source(), b)
+				return nil
+			case "profiles":
+				for _, p := range config.Profiles() {
+					if p == config.Profile() {
+						fmt.Printf("* %s\n", p)
+					} else {
+						fmt.Printf("  %s\n", p)
+					}
+				}
+				return nil
+			case "validate":
+				// the config is validated before every command runs
+				fmt.Printf("profile %s from %s is valid\n", config.Profile(), config.Source())
+				return nil
+			case "migrate":
+				return migrateConfig()
+			default:
+				return fmt.Errorf("unknown config command %q, run what-it-do config -h for help", args[0])
+			}
+		}
+	},
+}
+
+// migrateConfig writes config.yaml from the legacy json files and moves them
+// aside.
+func migrateConfig() error {
+	if !config.IsLegacy() {
+		return fmt.Errorf("%s already exists", config.Dir(config.FileName))
+	}
+	b, files, err := config.LegacyYAML()
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("there are no json config files to migrate")
+	}
+	f, err := os.OpenFile(config.Dir(config.FileName), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists", config.Dir(config.FileName))
+	} else if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		err = os.Rename(file, file+".bak")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("moved %s into %s\n", file, config.FileName)
+	}
+	return nil
+}
+
+// printPaths lists every file and directory the tool reads or writes.
+func printPaths(g *globalOptions) error {
+	tokens := config.TokenDir()
+	switch g.settings.TokenStore {
+	case tokenStoreKeyring:
+		tokens = "system keyring, service " + keyringService
+	}
+	profileDir := "-"
+	if config.Profile() != "" {
+		profileDir = config.Dir("profiles", config.Profile())
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "config dir\t%s\n", config.Dir())
+	if config.IsLegacy() {
+		fmt.Fprintf(w, "config file\t%s (missing, using the json files)\n", config.Dir(config.FileName))
+	} else {
+		fmt.Fprintf(w, "config file\t%s\n", config.Source())
+	}
+	fmt.Fprintf(w, "profile creds\t%s\n", profileDir)
+	fmt.Fprintf(w, "tokens\t%s\n", tokens)
+	fmt.Fprintf(w, "http cache\t%s\n", config.HTTPCacheDir())
+	fmt.Fprintf(w, "repository cache\t%s\n", config.CacheDir("bitbucket_repositories.json"))
+	return w.Flush()
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/abibby/what-it-do/config"
+)
+
+var reportCommand = &command{
+	name:  "report",
+	args:  "",
+	short: "print the timesheet rows for a day",
+	long: `
+Every configured source is included unless one or more source flags are given.
+Explanations of how rows were calculated are printed to stderr with -explain.
+`,
+	flags: func(fs *flag.FlagSet, g *globalOptions) func(args []string) error {
+		opts := &reportOptions{}
+		opts.register(fs)
+		fs.BoolVar(&opts.explain, "explain", false, "print how each row was calculated to stderr")
+		return func(args []string) error {
+			if len(args) > 0 {
+				return fmt.Errorf("unexpected arguments %v", args)
+			}
+			day, err := opts.day(g)
+			if err != nil {
+				return err
+			}
+			// the rows of the sources that worked are printed before the
+			// errors of the ones that failed are returned
+			rows, sourceErr := opts.rows(g, day)
+			err = writeRows(os.Stdout, g.output, rows)
+			if err != nil {
+				return err
+			}
+			if opts.explain {
+				writeExplanations(os.Stderr, rows)
+			}
+			return sourceErr
+		}
+	},
+}
+
+// reportOptions selects the day and sources of a report.
+type reportOptions struct {
+	calendar  bool
+	jira      bool
+	bitbucket bool
+	github    bool
+	gitlab    bool
+	git       bool
+	date      string
+	tz        string
+	explain   bool
+}
+
+func (o *reportOptions) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.calendar, "calendar", false, "include calendar events")
+	fs.BoolVar(&o.jira, "jira", false, "include jira issues")
+	fs.BoolVar(&o.bitbucket, "bitbucket", false, "include bitbucket code reviews")
+	fs.BoolVar(&o.github, "github", false, "include github pull requests")
+	fs.BoolVar(&o.gitlab, "gitlab", false, "include gitlab activity")
+	fs.BoolVar(&o.git, "git", false, "include commits from local git repositories")
+	fs.StringVar(&o.date, "date", "", "the date to get info for, defaults to today")
+	fs.StringVar(&o.tz, "tz", "", "the time zone used for day boundaries, defaults to time_zone in the config or the local zone")
+}
+
+func (o *reportOptions) all() bool {
+	return !o.calendar && !o.jira && !o.bitbucket && !o.github && !o.gitlab && !o.git
+}
+
+// day returns the start of the selected day.
+func (o *reportOptions) day(g *globalOptions) (time.Time, error) {
+	tz := o.tz
+	if tz == "" {
+		tz = g.settings.TimeZone
+	}
+	loc, err := loadLocation(tz)
+	if err != nil {
+		return time.Time{}, err
+	}
+	day := o.date
+	if day == "" {
+		day = time.Now().In(loc).Format(time.DateOnly)
+	}
+	now, err := time.ParseInLocation(time.DateOnly, day, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date: %w", err)
+	}
+	return startOfDay(now), nil
+}
+
+// rows fetches the rows for day from the selected sources. A failing source
+// doesn't stop the others, the rows that were found are returned with the
+// errors of the failed sources joined.
+func (o *reportOptions) rows(g *globalOptions, day time.Time) ([]*Row, error) {
+	start := startOfDay(day)
+	end := endOfDay(day)
+	all := o.all()
+
+	defer useReportCache(end, g.settings.HTTPCacheTTL.Or(defaultHTTPCacheTTL))()
+
+	rows := []*Row{}
+	errs := []error{}
+
+	// the calendar is fetched first so activity from the other sources can be
+	// suppressed on days off
+	var calRows []*Row
+	if all || o.calendar {
+		var err error
+		calRows, err = addCalenderEvents(start, end)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("calendar: %w", err))
+		}
+	}
+	dayOff := slices.ContainsFunc(calRows, func(r *Row) bool {
+		return r.TimeOff
+	})
+	timeOffActivity := timeOffSuppress
+	if dayOff {
+		calCfg, err := readCalendarConfig()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("calendar: %w", err))
+		} else {
+			timeOffActivity = calCfg.TimeOffActivity
+		}
+	}
+
+	activity := func(source string, fetch func(start, end time.Time) ([]*Row, error)) {
+		if dayOff && timeOffActivity == timeOffSuppress {
+			slog.Info("Skipping source on a day off", "source", source)
+			return
+		}
+		activityRows, err := fetch(start, end)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source, err))
+		}
+		if dayOff && len(activityRows) > 0 {
+			slog.Warn("Found activity on a day off", "source", source, "rows", len(activityRows))
+		}
+		rows = append(rows, activityRows...)
+	}
+
+	if all || o.bitbucket {
+		activity("bitbucket", getCodeReviews)
+	}
+	if o.github || (all && config.Exists("github_creds.json")) {
+		activity("github", getGitHubActivity)
+	}
+	if o.gitlab || (all && gitLabConfigured()) {
+		activity("gitlab", getGitLabActivity)
+	}
+	if o.git || (all && config.HasSection("git")) {
+		activity("git", getGitCommits)
+	}
+	rows = append(rows, calRows...)
+
+	if all || o.jira {
+		activity("jira", addJiraIssues)
+	}
+	return rows, errors.Join(errs...)
+}
+
+type jsonRow struct {
+	Date        string  `json:"date"`
+	Project     string  `json:"project"`
+	SubCategory string  `json:"sub_category"`
+	Hours       float64 `json:"hours,omitempty"`
+	JiraID      string  `json:"jira_id,omitempty"`
+	Description string  `json:"description"`
+	TimeOff     bool    `json:"time_off,omitempty"`
+	Explanation string  `json:"explanation,omitempty"`
+}
+
+func writeRows(w io.Writer, format string, rows []*Row) error {
+	if format == outputJSON {
+		jsonRows := make([]*jsonRow, len(rows))
+		for i, r := range rows {
+			jsonRows[i] = &jsonRow{
+				Date:        r.Date.Format(time.DateOnly),
+				Project:     r.Project,
+				SubCategory: r.SubCategory,
+				Hours:       r.Hours.Hours(),
+				JiraID:      r.JiraID,
+				Description: r.Description,
+				TimeOff:     r.TimeOff,
+				Explanation: r.Explanation,
+			}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(jsonRows)
+	}
+
+	out := csv.NewWriter(w)
+	if format == outputTSV {
+		out.Comma = '\t'
+	}
+	for _, row := range rows {
+		err := out.Write(row.ToCSVRow())
+		if err != nil {
+			return err
+		}
+	}
+	if format == outputTSV {
+		// a blank line separates days when pasting several reports
+		err := out.Write([]string{})
+		if err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func writeExplanations(w io.Writer, rows []*Row) {
+	for _, row := range rows {
+		if row.Explanation == "" {
+			continue
+		}
+		fmt.Fprintf(w, "%s%s %s: %s\n", row.Project, row.SubCategory, row.Description, row.Explanation)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var serveCommand = &command{
+	name:  "serve",
+	args:  "",
+	short: "serve reports over http",
+	long: `
+GET /report returns the report for today. It accepts the report flags as query
+parameters, e.g. /report?date=2024-01-02&jira=true&output=json. Invalid
+parameters return 400 Bad Request. When a source fails, like an expired token,
+the response is 500 Internal Server Error with the errors instead of a report
+that is missing the source.
+`,
+	flags: func(fs *flag.FlagSet, g *globalOptions) func(args []string) error {
+		addr := fs.String("addr", "127.0.0.1:8080", "the address to listen on")
+		return func(args []string) error {
+			if len(args) > 0 {
+				return fmt.Errorf("unexpected arguments %v", args)
+			}
+			mux := http.NewServeMux()
+			mux.Handle("GET /report", reportHandler(g))
+			slog.Warn("Serving reports", "addr", "http://"+*addr+"/report")
+			srv := &http.Server{
+				Addr:              *addr,
+				Handler:           mux,
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			return srv.ListenAndServe()
+		}
+	},
+}
+
+func reportHandler(g *globalOptions) http.Handler {
+	// the sources share package level state, one report runs at a time
+	var mtx sync.Mutex
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		opts := &reportOptions{
+			date: q.Get("date"),
+			tz:   q.Get("tz"),
+		}
+		for _, p := range []struct {
+			name string
+			v    *bool
+		}{
+			{"calendar", &opts.calendar},
+			{"jira", &opts.jira},
+			{"bitbucket", &opts.bitbucket},
+			{"github", &opts.github},
+			{"gitlab", &opts.gitlab},
+			{"git", &opts.git},
+		} {
+			if !q.Has(p.name) {
+				continue
+			}
+			b, err := strconv.ParseBool(q.Get(p.name))
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid %s %q, must be true or false", p.name, q.Get(p.name)), http.StatusBadRequest)
+				return
+			}
+			*p.v = b
+		}
+		day, err := opts.day(g)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		format := g.output
+		if q.Has("output") {
+			format = q.Get("output")
+		}
+		if !slices.Contains([]string{outputTSV, outputCSV, outputJSON}, format) {
+			http.Error(w, fmt.Sprintf("invalid output format %q", format), http.StatusBadRequest)
+			return
+		}
+
+		mtx.Lock()
+		rows, err := opts.rows(g, day)
+		mtx.Unlock()
+		if err != nil {
+			slog.Error("Failed to build report", "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		switch format {
+		case outputJSON:
+			w.Header().Set("Content-Type", "application/json")
+		case outputCSV:
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		default:
+			w.Header().Set("Content-Type", "text/tab-separated-values; charset=utf-8")
+		}
+		err = writeRows(w, format, rows)
+		if err != nil {
+			slog.Error("Failed to write report", "err", err)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/abibby/what-it-do/config"
+)
+
+func TestReportHandlerBadRequest(t *testing.T) {
+	g := &globalOptions{output: outputJSON, settings: &settings{}}
+	testCases := []struct {
+		query string
+		body  string
+	}{
+		{"date=yesterday", "invalid date"},
+		{"date=2024-11-12&tz=Mars/Olympus", `invalid time zone "Mars/Olympus"`},
+		{"date=2024-11-12&jira=yes", `invalid jira "yes"`},
+		{"date=2024-11-12&output=xml", `invalid output format "xml"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			reportHandler(g).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/report?"+tc.query, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tc.body) {
+				t.Errorf("expected body to contain %q, got %q", tc.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestReportHandlerSourceError(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(path.Join(dir, config.FileName), []byte("git:\n  repositories: [\"[\"]\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.SetDir(dir)
+	t.Cleanup(func() { config.SetDir("") })
+	err = config.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := &globalOptions{output: outputJSON, settings: &settings{}}
+	w := httptest.NewRecorder()
+	reportHandler(g).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/report?date=2024-11-12&git=true", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	if want := "git: invalid repository pattern ["; !strings.Contains(w.Body.String(), want) {
+		t.Errorf("expected body to contain %q, got %q", want, w.Body.String())
+	}
+}
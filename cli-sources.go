@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/abibby/what-it-do/config"
+)
+
+var sourcesCommand = &command{
+	name:  "sources",
+	args:  "",
+	short: "list the activity sources and whether they are configured",
+	flags: func(fs *flag.FlagSet, g *globalOptions) func(args []string) error {
+		return func(args []string) error {
+			if len(args) > 0 {
+				return fmt.Errorf("unexpected arguments %v", args)
+			}
+			return listSources(g.output)
+		}
+	},
+}
+
+type sourceInfo struct {
+	Name string `json:"name"`
+	Flag string `json:"flag"`
+	// Creds are the creds files in the config directory that configure the
+	// source.
+	Creds []string `json:"creds"`
+	// Sections are the config sections that configure the source.
+	Sections []string `json:"sections"`
+	// Default sources are included in reports without source flags even if
+	// they aren't configured.
+	Default    bool `json:"default"`
+	Configured bool `json:"configured"`
+}
+
+func sources() []*sourceInfo {
+	list := []*sourceInfo{
+		{Name: "calendar", Flag: "-calendar", Creds: []string{"google_creds.json", "microsoft_creds.json"}, Sections: []string{"calendar"}, Default: true},
+		{Name: "jira", Flag: "-jira", Creds: []string{"atlassian_creds.json"}, Sections: []string{"jira"}, Default: true},
+		{Name: "bitbucket", Flag: "-bitbucket", Creds: []string{"bitbucket_creds.json"}, Sections: []string{"bitbucket", "bitbucket_server"}, Default: true},
+		{Name: "github", Flag: "-github", Creds: []string{"github_creds.json"}, Sections: []string{"github"}},
+		{Name: "gitlab", Flag: "-gitlab", Creds: []string{"gitlab_creds.json"}, Sections: []string{"gitlab"}},
+		{Name: "git", Flag: "-git", Creds: []string{}, Sections: []string{"git"}},
+	}
+	for _, s := range list {
+		for _, f := range s.Creds {
+			if config.Exists(f) {
+				s.Configured = true
+			}
+		}
+		for _, section := range s.Sections {
+			if config.HasSection(section) {
+				s.Configured = true
+			}
+		}
+	}
+	return list
+}
+
+func listSources(format string) error {
+	list := sources()
+	if format == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tFLAG\tCONFIGURED\tIN REPORTS\tCREDS\tSECTIONS")
+	for _, s := range list {
+		inReports := "with flag"
+		if s.Default || s.Configured {
+			inReports = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\t%s\n", s.Name, s.Flag, s.Configured, inReports, strings.Join(s.Creds, ", "), strings.Join(s.Sections, ", "))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+var submitCommand = &command{
+	name:  "submit",
+	args:  "",
+	short: "log the report's hours as jira worklogs",
+	long: `
+Rows with a jira key and hours are added as worklogs on the issue. Rows that
+already have a worklog from you on the same day with the same description are
+skipped, so submitting a day twice doesn't double count it.
+
+The worklogs are only printed until -dry-run=false is given. Nothing is
+submitted when a source fails, the report would be missing its hours.
+`,
+	flags: func(fs *flag.FlagSet, g *globalOptions) func(args []string) error {
+		opts := &reportOptions{}
+		opts.register(fs)
+		dryRun := fs.Bool("dry-run", true, "print the worklogs without adding them, set to false to add them")
+		return func(args []string) error {
+			if len(args) > 0 {
+				return fmt.Errorf("unexpected arguments %v", args)
+			}
+			day, err := opts.day(g)
+			if err != nil {
+				return err
+			}
+			rows, err := opts.rows(g, day)
+			if err != nil {
+				return fmt.Errorf("not submitting a report with failed sources: %w", err)
+			}
+			return submitWorklogs(rows, *dryRun)
+		}
+	},
+}
+
+func submitWorklogs(rows []*Row, dryRun bool) error {
+	worklogRows := []*Row{}
+	for _, row := range rows {
+		if row.JiraID != "" && row.Hours > 0 {
+			worklogRows = append(worklogRows, row)
+		}
+	}
+	if len(worklogRows) == 0 {
+		fmt.Fprintln(os.Stderr, "no rows with a jira key and hours to submit")
+		return nil
+	}
+
+	if dryRun {
+		for _, row := range worklogRows {
+			fmt.Printf("%s\t%s\t%s\t%s\n", row.Date.Format(time.DateOnly), row.JiraID, row.Hours, worklogComment(row))
+		}
+		return nil
+	}
+
+	jiraClient, err := getJiraClient()
+	if err != nil {
+		return err
+	}
+	self, _, err := jiraClient.User.GetSelf()
+	if err != nil {
+		return fmt.Errorf("get self: %w", err)
+	}
+
+	for _, row := range worklogRows {
+		exists, err := hasWorklog(jiraClient, self, row)
+		if err != nil {
+			return fmt.Errorf("%s: %w", row.JiraID, err)
+		}
+		if exists {
+			slog.Info("Skipping existing worklog", "issue", row.JiraID, "description", row.Description)
+			continue
+		}
+
+		started := jira.Time(row.Date)
+		_, _, err = jiraClient.Issue.AddWorklogRecord(row.JiraID, &jira.WorklogRecord{
+			Comment:          worklogComment(row),
+			Started:          &started,
+			TimeSpentSeconds: int(row.Hours.Seconds()),
+		})
+		if err != nil {
+			return fmt.Errorf("add worklog to %s: %w", row.JiraID, err)
+		}
+		fmt.Printf("logged %s on %s\n", row.Hours, row.JiraID)
+	}
+	return nil
+}
+
+func worklogComment(row *Row) string {
+	return row.Project + row.SubCategory + ": " + row.Description
+}
+
+func hasWorklog(jiraClient *jira.Client, self *jira.User, row *Row) (bool, error) {
+	worklogs, _, err := jiraClient.Issue.GetWorklogs(row.JiraID)
+	if err != nil {
+		return false, err
+	}
+	comment := worklogComment(row)
+	for _, w := range worklogs.Worklogs {
+		if w.Author == nil || w.Author.AccountID != self.AccountID || w.Started == nil {
+			continue
+		}
+		started := time.Time(*w.Started).In(row.Date.Location())
+		if w.Comment == comment && startOfDay(started).Equal(startOfDay(row.Date)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
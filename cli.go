@@ -0,0 +1,317 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/abibby/salusa/clog"
+	"github.com/abibby/what-it-do/config"
+	"github.com/abibby/what-it-do/ezoauth"
+	"github.com/abibby/what-it-do/fakes"
+	"github.com/abibby/what-it-do/replay"
+)
+
+// globalOptions are accepted before the command and by every command.
+type globalOptions struct {
+	profile   string
+	configDir string
+	logLevel  string
+	verbose   bool
+	debug     bool
+	output    string
+	offline   bool
+	record    string
+	replace   string
+	replay    string
+	fake      string
+
+	settings *settings
+	// cleanup holds what setup started that has to be stopped when the
+	// command is done, like the fake servers.
+	cleanup []func()
+}
+
+const (
+	outputTSV  = "tsv"
+	outputCSV  = "csv"
+	outputJSON = "json"
+)
+
+func (g *globalOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.profile, "profile", g.profile, "the config profile to use, defaults to $WHAT_IT_DO_PROFILE")
+	fs.StringVar(&g.configDir, "config-dir", g.configDir, "the config directory, defaults to $WHAT_IT_DO_CONFIG_DIR or $XDG_CONFIG_HOME/what-it-do")
+	fs.StringVar(&g.logLevel, "log-level", g.logLevel, "log level, one of debug, info, warn or error")
+	fs.BoolVar(&g.verbose, "v", g.verbose, "shorthand for -log-level info")
+	fs.BoolVar(&g.debug, "vv", g.debug, "shorthand for -log-level debug")
+	fs.StringVar(&g.output, "output", g.output, "output format, one of tsv, csv or json")
+	fs.BoolVar(&g.offline, "offline", g.offline, "only use cached api responses")
+	fs.StringVar(&g.record, "record", g.record, "development: record api responses to fixtures in this directory")
+	fs.StringVar(&g.replace, "record-replace", g.replace, "development: comma separated old=new pairs, like emails and account ids, replaced in recorded fixtures")
+	fs.StringVar(&g.replay, "replay", g.replay, "development: answer api requests from the fixtures in this directory")
+	fs.StringVar(&g.fake, "fake", g.fake, "development: answer api requests from fake servers seeded with this yaml scenario")
+}
+
+func (g *globalOptions) level() (slog.Level, error) {
+	if g.debug {
+		return slog.LevelDebug, nil
+	} else if g.verbose {
+		return slog.LevelInfo, nil
+	}
+	var level slog.Level
+	err := level.UnmarshalText([]byte(g.logLevel))
+	if err != nil {
+		return 0, fmt.Errorf("invalid log level %q", g.logLevel)
+	}
+	return level, nil
+}
+
+// setup applies the global options and loads the config, it runs after the
+// command's flags are parsed.
+func (g *globalOptions) setup() error {
+	level, err := g.level()
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(clog.DefaultHandler(level)))
+
+	if !slices.Contains([]string{outputTSV, outputCSV, outputJSON}, g.output) {
+		return fmt.Errorf("invalid output format %q, must be one of %s, %s or %s", g.output, outputTSV, outputCSV, outputJSON)
+	}
+
+	config.SetDir(g.configDir)
+	err = config.Load(g.profile)
+	if err != nil {
+		return err
+	}
+	err = config.Validate()
+	if err != nil {
+		return err
+	}
+
+	s, err := readSettings()
+	if err != nil {
+		return err
+	}
+	g.settings = s
+	if s.JiraKeyPattern != "" {
+		jiraRE = regexp.MustCompile(s.JiraKeyPattern)
+	}
+	store, err := newTokenStore(s.TokenStore)
+	if err != nil {
+		return err
+	}
+	if s.TokenNamespace != "" {
+		store = &namespacedStore{store: store, namespace: s.TokenNamespace}
+	}
+	ezoauth.DefaultStore = store
+	ezoauth.DefaultFlow, err = ezoauth.ParseFlow(s.AuthFlow)
+	if err != nil {
+		return err
+	}
+	setupHTTPCache(s, g.offline)
+	return g.setupDevTransport()
+}
+
+// close stops what setup started.
+func (g *globalOptions) close() {
+	for _, f := range slices.Backward(g.cleanup) {
+		f()
+	}
+	g.cleanup = nil
+}
+
+// setupDevTransport replaces the cache with the fake servers, fixtures or
+// recorder from the development flags. Recording the fake servers saves
+// their responses as fixtures.
+func (g *globalOptions) setupDevTransport() error {
+	var wrap func(service string, rt http.RoundTripper) http.RoundTripper
+	if g.fake != "" {
+		scenario, err := fakes.LoadScenario(g.fake)
+		if err != nil {
+			return err
+		}
+		srv, err := fakes.NewServer(scenario)
+		if err != nil {
+			return err
+		}
+		g.cleanup = append(g.cleanup, srv.Close)
+		ezoauth.Offline = true
+		wrap = srv.Transport
+	} else if g.replay != "" {
+		ezoauth.Offline = true
+		wrap = replay.NewPlayer(g.replay).Transport
+	}
+
+	if g.record != "" {
+		replace, err := parseReplacements(g.replace)
+		if err != nil {
+			return err
+		}
+		if len(replace) == 0 {
+			slog.Warn("Recorded fixtures keep your emails and account ids, replace them with -record-replace")
+		}
+		recorder := replay.NewRecorder(g.record, replace...)
+		if wrap == nil {
+			wrap = recorder.Transport
+		} else {
+			inner := wrap
+			wrap = func(service string, rt http.RoundTripper) http.RoundTripper {
+				return recorder.Transport(service, inner(service, rt))
+			}
+		}
+	}
+	if wrap != nil {
+		ezoauth.WrapTransport = wrap
+	}
+	return nil
+}
+
+// parseReplacements parses comma separated old=new pairs into the old, new
+// list replay.NewRecorder takes.
+func parseReplacements(s string) ([]string, error) {
+	replace := []string{}
+	if s == "" {
+		return replace, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		from, to, ok := strings.Cut(pair, "=")
+		if !ok || from == "" {
+			return nil, fmt.Errorf("invalid -record-replace pair %q, expected old=new", pair)
+		}
+		replace = append(replace, from, to)
+	}
+	return replace, nil
+}
+
+type command struct {
+	name  string
+	args  string
+	short string
+	long  string
+	// flags registers the command's flags and returns the function that runs
+	// it once they are parsed.
+	flags func(fs *flag.FlagSet, g *globalOptions) func(args []string) error
+}
+
+func commands() []*command {
+	return []*command{
+		reportCommand,
+		submitCommand,
+		authCommand,
+		configCommand,
+		sourcesCommand,
+		cacheCommand,
+		serveCommand,
+	}
+}
+
+func findCommand(name string) *command {
+	for _, c := range commands() {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func rootUsage(w io.Writer) {
+	fmt.Fprintf(w, "usage: what-it-do [global flags] <command> [flags] [args]\n\n")
+	fmt.Fprintf(w, "commands:\n")
+	for _, c := range commands() {
+		fmt.Fprintf(w, "  %-8s %s\n", c.name, c.short)
+	}
+	fmt.Fprintf(w, "\nreport is run when no command is given. Run what-it-do <command> -h for the command's flags.\n\nglobal flags:\n")
+	fs := flag.NewFlagSet("what-it-do", flag.ContinueOnError)
+	fs.SetOutput(w)
+	newGlobalOptions().register(fs)
+	fs.PrintDefaults()
+}
+
+func newGlobalOptions() *globalOptions {
+	return &globalOptions{
+		profile:   os.Getenv("WHAT_IT_DO_PROFILE"),
+		configDir: os.Getenv("WHAT_IT_DO_CONFIG_DIR"),
+		logLevel:  "warn",
+		output:    outputTSV,
+	}
+}
+
+// runCLI runs the command in args and returns the exit code.
+func runCLI(args []string) int {
+	g := newGlobalOptions()
+
+	root := flag.NewFlagSet("what-it-do", flag.ContinueOnError)
+	root.SetOutput(io.Discard)
+	g.register(root)
+	err := root.Parse(args)
+
+	var cmd *command
+	if errors.Is(err, flag.ErrHelp) {
+		rootUsage(os.Stdout)
+		return 0
+	} else if err != nil {
+		// command flags before the command name, e.g. what-it-do -jira
+		cmd = reportCommand
+	} else if root.NArg() > 0 && root.Arg(0) == "help" {
+		if c := findCommand(root.Arg(1)); c != nil {
+			return runCommand(c, g, []string{"-h"})
+		}
+		rootUsage(os.Stdout)
+		return 0
+	} else if root.NArg() > 0 && findCommand(root.Arg(0)) != nil {
+		cmd = findCommand(root.Arg(0))
+		args = root.Args()[1:]
+	} else if root.NArg() > 0 && !strings.HasPrefix(root.Arg(0), "-") {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", root.Arg(0))
+		rootUsage(os.Stderr)
+		return 2
+	} else {
+		cmd = reportCommand
+		args = root.Args()
+	}
+
+	return runCommand(cmd, g, args)
+}
+
+func runCommand(c *command, g *globalOptions, args []string) int {
+	fs := flag.NewFlagSet("what-it-do "+c.name, flag.ContinueOnError)
+	g.register(fs)
+	run := c.flags(fs, g)
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintf(w, "usage: what-it-do %s [flags] %s\n\n%s\n", c.name, c.args, c.short)
+		if c.long != "" {
+			fmt.Fprintf(w, "\n%s\n", strings.TrimSpace(c.long))
+		}
+		fmt.Fprintf(w, "\nflags:\n")
+		fs.PrintDefaults()
+	}
+	err := fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	} else if err != nil {
+		return 2
+	}
+
+	defer g.close()
+	err = g.setup()
+	if err != nil {
+		slog.Error("Fatal error", "err", err)
+		return 1
+	}
+
+	err = run(fs.Args())
+	if err != nil {
+		slog.Error("Fatal error", "err", err)
+		return 1
+	}
+	return 0
+}
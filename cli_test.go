@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestParseReplacements(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  []string
+		err   bool
+	}{
+		{"", []string{}, false},
+		{"jane@corp.com=test.user@example.com", []string{"jane@corp.com", "test.user@example.com"}, false},
+		{"jane@corp.com=test.user@example.com,557058:abc=account-id", []string{"jane@corp.com", "test.user@example.com", "557058:abc", "account-id"}, false},
+		{"{uuid}=", []string{"{uuid}", ""}, false},
+		{"jane@corp.com", nil, true},
+		{"=test.user@example.com", nil, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := parseReplacements(tc.value)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(got, "|") != strings.Join(tc.want, "|") || len(got) != len(tc.want) {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSubmitDryRunByDefault(t *testing.T) {
+	fs := flag.NewFlagSet("submit", flag.ContinueOnError)
+	submitCommand.flags(fs, newGlobalOptions())
+	if f := fs.Lookup("dry-run"); f == nil || f.DefValue != "true" {
+		t.Errorf("expected submit to default to a dry run, got %v", f)
+	}
+}
@@ -1,14 +1,135 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path"
+	"time"
 )
 
+const appName = "what-it-do"
+
+var dirOverride string
+
+// SetDir replaces the config directory, an empty dir restores the default.
+func SetDir(dir string) {
+	dirOverride = dir
+	current = nil
+}
+
+// Dir returns a path in the config directory, $XDG_CONFIG_HOME/what-it-do or
+// ~/.config/what-it-do.
 func Dir(parts ...string) string {
-	home, err := os.UserHomeDir()
+	if dirOverride != "" {
+		return path.Join(append([]string{dirOverride}, parts...)...)
+	}
+	return xdgDir("XDG_CONFIG_HOME", ".config", "config", parts)
+}
+
+// CacheDir returns a path in the cache directory, $XDG_CACHE_HOME/what-it-do
+// or ~/.cache/what-it-do. Everything in it can be deleted.
+func CacheDir(parts ...string) string {
+	return xdgDir("XDG_CACHE_HOME", ".cache", "cache", parts)
+}
+
+// DataDir returns a path in the data directory, $XDG_DATA_HOME/what-it-do or
+// ~/.local/share/what-it-do.
+func DataDir(parts ...string) string {
+	return xdgDir("XDG_DATA_HOME", ".local/share", "data", parts)
+}
+
+// TokenDir is where oauth tokens are saved.
+func TokenDir() string {
+	return DataDir("tokens")
+}
+
+// HTTPCacheDir is where api responses are cached.
+func HTTPCacheDir() string {
+	return CacheDir("http")
+}
+
+// xdgDir resolves a base directory from env, falling back to home/homeRel.
+// Without either it uses .what-it-do/<name> in the working directory rather
+// than a directory in /.
+func xdgDir(env, homeRel, name string, parts []string) string {
+	base := os.Getenv(env)
+	// the spec says relative paths are invalid and should be ignored
+	if base == "" || !path.IsAbs(base) {
+		home, err := os.UserHomeDir()
+		if err != nil || home == "" {
+			return path.Join(append([]string{"." + appName, name}, parts...)...)
+		}
+		base = path.Join(home, homeRel)
+	}
+	return path.Join(append([]string{base, appName}, parts...)...)
+}
+
+var profile string
+
+// Profile returns the selected profile.
+func Profile() string {
+	return profile
+}
+
+// Path returns the path of the config file name, preferring the copy in
+// profiles/<profile> when it exists so profiles can use different creds.
+func Path(name string) string {
+	if profile != "" {
+		p := Dir("profiles", profile, name)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return Dir(name)
+}
+
+// ReadJSON decodes the json file name in the config directory into v. If the
+// file doesn't exist the returned error wraps os.ErrNotExist.
+func ReadJSON(name string, v any) error {
+	b, err := os.ReadFile(Path(name))
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(b, v)
+	if err != nil {
+		return fmt.Errorf("invalid config file %s: %w", name, err)
+	}
+	return nil
+}
+
+// Exists reports whether the file name exists in the config directory.
+func Exists(name string) bool {
+	_, err := os.Stat(Path(name))
+	return err == nil
+}
+
+// Duration is a time.Duration that is written in config files as a string
+// like "1h30m".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	err := json.Unmarshal(b, &s)
 	if err != nil {
-		home = "/"
+		return fmt.Errorf("duration must be a string: %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Or returns d or def if d is zero.
+func (d Duration) Or(def time.Duration) time.Duration {
+	if d == 0 {
+		return def
 	}
-	return path.Join(append([]string{home, ".config/what-it-do"}, parts...)...)
+	return time.Duration(d)
 }
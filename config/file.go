@@ -0,0 +1,495 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the config file in Dir. When it doesn't exist the older per
+// source json files are read instead.
+const FileName = "config.yaml"
+
+// EnvPrefix starts the environment variables that override config values.
+// Nested keys are separated by a double underscore, WHAT_IT_DO_TIME_ZONE sets
+// time_zone and WHAT_IT_DO_CALENDAR__OVERLAP_POLICY sets calendar.overlap_policy.
+const EnvPrefix = "WHAT_IT_DO_"
+
+// ReservedEnv are environment variables with EnvPrefix that aren't config
+// overrides.
+var ReservedEnv = []string{
+	EnvPrefix + "PROFILE",
+	EnvPrefix + "CONFIG_DIR",
+	EnvPrefix + "TOKEN_PASSPHRASE",
+}
+
+// defaultProfile is the profile name used by config files without profiles
+// and by the legacy json files.
+const defaultProfile = "default"
+
+type loadedConfig struct {
+	// source describes where the values came from for error messages.
+	source   string
+	profile  string
+	profiles []string
+	values   map[string]any
+	// lines are the line numbers of each section in config.yaml.
+	lines map[string]int
+}
+
+var current *loadedConfig
+
+var schemas = map[string]func() any{}
+
+// settingsSection holds the top level scalar values of a profile.
+const settingsSection = ""
+
+// RegisterSection declares a section of a profile, newValue returns the
+// struct it is decoded into. It is used to validate the config.
+func RegisterSection(name string, newValue func() any) {
+	schemas[name] = newValue
+}
+
+// RegisterSettings declares the struct the top level values of a profile,
+// everything that isn't a section, are decoded into.
+func RegisterSettings(newValue func() any) {
+	schemas[settingsSection] = newValue
+}
+
+// Load reads config.yaml and selects profile. An empty profile uses the
+// default_profile from the file. If config.yaml doesn't exist the legacy json
+// files are loaded as a single profile.
+func Load(profileName string) error {
+	profile = profileName
+
+	b, err := os.ReadFile(Dir(FileName))
+	var c *loadedConfig
+	if errors.Is(err, os.ErrNotExist) {
+		c, err = loadLegacy()
+	} else if err != nil {
+		return err
+	} else {
+		c, err = parseFile(Dir(FileName), b, profileName)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = applyEnv(c.values, os.Environ())
+	if err != nil {
+		return err
+	}
+
+	current = c
+	profile = c.profile
+	return nil
+}
+
+func loaded() *loadedConfig {
+	if current == nil {
+		err := Load(profile)
+		if err != nil {
+			// the error is reported again by Validate or the section read
+			return &loadedConfig{values: map[string]any{}, profile: profile}
+		}
+	}
+	return current
+}
+
+// Source returns the file the config was loaded from.
+func Source() string {
+	return loaded().source
+}
+
+// Profiles returns the names of the profiles in config.yaml.
+func Profiles() []string {
+	return loaded().profiles
+}
+
+func parseFile(path string, b []byte, profileName string) (*loadedConfig, error) {
+	doc := &yaml.Node{}
+	err := yaml.Unmarshal(b, doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	c := &loadedConfig{
+		source: path,
+		values: map[string]any{},
+		lines:  map[string]int{},
+	}
+	if len(doc.Content) == 0 {
+		c.profile = defaultProfile
+		return c, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: expected a mapping at the top level", path, root.Line)
+	}
+
+	var defaults, profiles *yaml.Node
+	defaultName := ""
+	rest := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		switch key.Value {
+		case "default_profile":
+			defaultName = value.Value
+		case "defaults":
+			defaults = value
+		case "profiles":
+			profiles = value
+		default:
+			rest.Content = append(rest.Content, key, value)
+		}
+	}
+
+	var selected *yaml.Node
+	if profiles == nil {
+		// a file without profiles is a single profile
+		c.profile = defaultProfile
+		c.profiles = []string{defaultProfile}
+		if profileName != "" && profileName != defaultProfile {
+			return nil, fmt.Errorf("%s: profile %q not found, the file has no profiles", path, profileName)
+		}
+		selected = rest
+	} else {
+		if len(rest.Content) > 0 {
+			return nil, fmt.Errorf("%s:%d: unknown top level key %q, settings go in a profile or defaults", path, rest.Content[0].Line, rest.Content[0].Value)
+		}
+		if profiles.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s:%d: profiles must be a mapping of names to profiles", path, profiles.Line)
+		}
+		for i := 0; i < len(profiles.Content); i += 2 {
+			c.profiles = append(c.profiles, profiles.Content[i].Value)
+		}
+
+		c.profile = profileName
+		if c.profile == "" {
+			c.profile = defaultName
+		}
+		if c.profile == "" && len(c.profiles) == 1 {
+			c.profile = c.profiles[0]
+		}
+		if c.profile == "" {
+			return nil, fmt.Errorf("%s: set default_profile or choose a profile with -profile, one of %s", path, strings.Join(c.profiles, ", "))
+		}
+		for i := 0; i < len(profiles.Content); i += 2 {
+			if profiles.Content[i].Value == c.profile {
+				selected = profiles.Content[i+1]
+			}
+		}
+		if selected == nil {
+			return nil, fmt.Errorf("%s: profile %q not found, must be one of %s", path, c.profile, strings.Join(c.profiles, ", "))
+		}
+	}
+
+	for _, n := range []*yaml.Node{defaults, selected} {
+		if n == nil || n.Kind == 0 || (n.Kind == yaml.ScalarNode && n.Tag == "!!null") {
+			continue
+		}
+		if n.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s:%d: a profile must be a mapping", path, n.Line)
+		}
+		v, err := nodeValue(n)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		merge(c.values, v.(map[string]any))
+		for i := 0; i < len(n.Content); i += 2 {
+			c.lines[n.Content[i].Value] = n.Content[i].Line
+		}
+	}
+	return c, nil
+}
+
+// nodeValue converts a yaml node to maps, slices and scalars. Timestamps are
+// kept as strings so they decode the same as they would from json.
+func nodeValue(n *yaml.Node) (any, error) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return nodeValue(n.Content[0])
+	case yaml.AliasNode:
+		return nodeValue(n.Alias)
+	case yaml.MappingNode:
+		m := map[string]any{}
+		for i := 0; i < len(n.Content); i += 2 {
+			v, err := nodeValue(n.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			m[n.Content[i].Value] = v
+		}
+		return m, nil
+	case yaml.SequenceNode:
+		s := make([]any, len(n.Content))
+		for i, item := range n.Content {
+			v, err := nodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			s[i] = v
+		}
+		return s, nil
+	default:
+		if n.Tag == "!!timestamp" {
+			return n.Value, nil
+		}
+		var v any
+		err := n.Decode(&v)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		return v, nil
+	}
+}
+
+// merge copies src into dst, merging nested maps.
+func merge(dst, src map[string]any) {
+	for k, v := range src {
+		srcMap, srcOK := v.(map[string]any)
+		dstMap, dstOK := dst[k].(map[string]any)
+		if srcOK && dstOK {
+			merge(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+func applyEnv(values map[string]any, environ []string) error {
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, EnvPrefix) || slices.Contains(ReservedEnv, key) {
+			continue
+		}
+		path := strings.Split(strings.ToLower(strings.TrimPrefix(key, EnvPrefix)), "__")
+
+		var v any
+		err := yaml.Unmarshal([]byte(value), &v)
+		if err != nil || v == nil {
+			v = value
+		}
+		// yaml stops at the end of a flow list, keep values like [A-Z]+-\d+
+		if _, ok := v.([]any); ok && !isFlowList(value) {
+			v = value
+		}
+		if _, ok := v.(map[string]any); ok {
+			return fmt.Errorf("%s: overrides must be a single value or a list", key)
+		}
+
+		m := values
+		for i, part := range path[:len(path)-1] {
+			next, ok := m[part].(map[string]any)
+			if !ok {
+				if m[part] != nil {
+					return fmt.Errorf("%s: %s is a single value, not a section", key, strings.Join(path[:i+1], "."))
+				}
+				next = map[string]any{}
+				m[part] = next
+			}
+			m = next
+		}
+		last := path[len(path)-1]
+		if _, ok := m[last].(map[string]any); ok {
+			return fmt.Errorf("%s: %s is a section, override its values with __", key, strings.Join(path, "."))
+		}
+		m[last] = v
+	}
+	return nil
+}
+
+func isFlowList(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]")
+}
+
+// legacyFile returns the json file a section was read from before
+// config.yaml.
+func legacyFile(section string) string {
+	if section == settingsSection {
+		return "settings.json"
+	}
+	return section + ".json"
+}
+
+func loadLegacy() (*loadedConfig, error) {
+	c := &loadedConfig{
+		source:   Dir(),
+		profile:  defaultProfile,
+		profiles: []string{defaultProfile},
+		values:   map[string]any{},
+	}
+	for name := range schemas {
+		v := map[string]any{}
+		err := ReadJSON(legacyFile(name), &v)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if name == settingsSection {
+			merge(c.values, v)
+		} else {
+			c.values[name] = v
+		}
+	}
+	return c, nil
+}
+
+// IsLegacy reports whether the config was read from json files because there
+// is no config.yaml.
+func IsLegacy() bool {
+	return loaded().lines == nil
+}
+
+// HasSection reports whether the profile sets the section.
+func HasSection(name string) bool {
+	_, ok := loaded().values[name]
+	return ok
+}
+
+// Section decodes the section of the selected profile into v using its json
+// tags. If the profile doesn't set the section the returned error wraps
+// os.ErrNotExist.
+func Section(name string, v any) error {
+	c := loaded()
+	value, ok := c.values[name]
+	if !ok {
+		return fmt.Errorf("%s: section %s: %w", c.location(name), name, os.ErrNotExist)
+	}
+	return c.decode(name, value, v)
+}
+
+// Settings decodes the top level values of the selected profile into v.
+func Settings(v any) error {
+	c := loaded()
+	return c.decode(settingsSection, c.settingsValues(), v)
+}
+
+func (c *loadedConfig) settingsValues() map[string]any {
+	settings := map[string]any{}
+	for k, v := range c.values {
+		if _, ok := schemas[k]; !ok {
+			settings[k] = v
+		}
+	}
+	return settings
+}
+
+func (c *loadedConfig) location(section string) string {
+	if c.lines == nil {
+		return Dir(legacyFile(section))
+	}
+	if line, ok := c.lines[section]; ok {
+		return fmt.Sprintf("%s:%d: profile %s", c.source, line, c.profile)
+	}
+	return fmt.Sprintf("%s: profile %s", c.source, c.profile)
+}
+
+var unknownFieldRE = regexp.MustCompile(`unknown field "([^"]*)"`)
+
+func (c *loadedConfig) decode(section string, value, v any) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(v)
+	if err != nil {
+		name := section
+		location := c.location(section)
+		if name == settingsSection {
+			name = "settings"
+			// point at the offending top level key
+			if m := unknownFieldRE.FindStringSubmatch(err.Error()); m != nil && c.lines != nil {
+				location = c.location(m[1])
+			}
+		}
+		return fmt.Errorf("%s: %s: %s", location, name, strings.TrimPrefix(err.Error(), "json: "))
+	}
+	return nil
+}
+
+// Validate decodes every section of the selected profile and returns all of
+// the problems found.
+func Validate() error {
+	if current == nil {
+		err := Load(profile)
+		if err != nil {
+			return err
+		}
+	}
+	c := current
+	errs := []error{}
+
+	names := []string{}
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		newValue := schemas[name]
+		if name == settingsSection {
+			errs = append(errs, c.decode(name, c.settingsValues(), newValue()))
+			continue
+		}
+		value, ok := c.values[name]
+		if !ok {
+			continue
+		}
+		errs = append(errs, c.decode(name, value, newValue()))
+	}
+	return errors.Join(errs...)
+}
+
+// LegacyYAML converts the legacy json files to a config.yaml with a single
+// profile. It returns the files that were converted.
+func LegacyYAML() ([]byte, []string, error) {
+	c, err := loadLegacy()
+	if err != nil {
+		return nil, nil, err
+	}
+	files := []string{}
+	for name := range schemas {
+		if Exists(legacyFile(name)) {
+			files = append(files, Path(legacyFile(name)))
+		}
+	}
+	sort.Strings(files)
+	b, err := marshalYAML(map[string]any{
+		"default_profile": c.profile,
+		"profiles": map[string]any{
+			c.profile: c.values,
+		},
+	})
+	return b, files, err
+}
+
+// Marshal returns the selected profile, with env overrides applied, as yaml.
+func Marshal() ([]byte, error) {
+	return marshalYAML(loaded().values)
+}
+
+func marshalYAML(v any) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	err := enc.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	err = enc.Close()
+	return buf.Bytes(), err
+}
@@ -0,0 +1,357 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestParseFile(t *testing.T) {
+	testCases := []struct {
+		name     string
+		file     string
+		profile  string
+		selected string
+		profiles []string
+		values   string
+		err      string
+	}{
+		{
+			name:     "empty",
+			file:     "",
+			selected: "default",
+			values:   `{}`,
+		},
+		{
+			name:     "no profiles",
+			file:     "time_zone: America/Toronto\ncalendar:\n  project: Meetings\n",
+			selected: "default",
+			profiles: []string{"default"},
+			values:   `{"calendar":{"project":"Meetings"},"time_zone":"America/Toronto"}`,
+		},
+		{
+			name:    "no profiles with a profile",
+			file:    "time_zone: America/Toronto\n",
+			profile: "work",
+			err:     `config.yaml: profile "work" not found, the file has no profiles`,
+		},
+		{
+			name: "default profile and defaults",
+			file: `default_profile: work
+defaults:
+  time_zone: America/Toronto
+  calendar:
+    project: Meetings
+    overlap_policy: split
+profiles:
+  work:
+    calendar:
+      project: Client
+  home:
+    time_zone: Europe/London
+`,
+			selected: "work",
+			profiles: []string{"work", "home"},
+			values:   `{"calendar":{"overlap_policy":"split","project":"Client"},"time_zone":"America/Toronto"}`,
+		},
+		{
+			name: "selected profile",
+			file: `default_profile: work
+defaults:
+  time_zone: America/Toronto
+profiles:
+  work: {}
+  home:
+    time_zone: Europe/London
+`,
+			profile:  "home",
+			selected: "home",
+			profiles: []string{"work", "home"},
+			values:   `{"time_zone":"Europe/London"}`,
+		},
+		{
+			name:     "single implicit profile",
+			file:     "profiles:\n  work:\n    time_zone: America/Toronto\n",
+			selected: "work",
+			profiles: []string{"work"},
+			values:   `{"time_zone":"America/Toronto"}`,
+		},
+		{
+			name:     "null profile",
+			file:     "defaults:\n  time_zone: America/Toronto\nprofiles:\n  work:\n",
+			selected: "work",
+			profiles: []string{"work"},
+			values:   `{"time_zone":"America/Toronto"}`,
+		},
+		{
+			name:     "timestamps stay strings",
+			file:     "since: 2024-11-12\n",
+			selected: "default",
+			profiles: []string{"default"},
+			values:   `{"since":"2024-11-12"}`,
+		},
+		{
+			name: "no default profile",
+			file: "profiles:\n  work: {}\n  home: {}\n",
+			err:  "config.yaml: set default_profile or choose a profile with -profile, one of work, home",
+		},
+		{
+			name:    "missing profile",
+			file:    "profiles:\n  work: {}\n  home: {}\n",
+			profile: "play",
+			err:     `config.yaml: profile "play" not found, must be one of work, home`,
+		},
+		{
+			name: "missing default profile",
+			file: "default_profile: play\nprofiles:\n  work: {}\n",
+			err:  `config.yaml: profile "play" not found, must be one of work`,
+		},
+		{
+			name: "unknown top level key",
+			file: "default_profile: work\ntime_zone: America/Toronto\nprofiles:\n  work: {}\n",
+			err:  `config.yaml:2: unknown top level key "time_zone", settings go in a profile or defaults`,
+		},
+		{
+			name: "profiles list",
+			file: "profiles:\n  - work\n",
+			err:  "config.yaml:2: profiles must be a mapping of names to profiles",
+		},
+		{
+			name: "scalar profile",
+			file: "profiles:\n  work: America/Toronto\n",
+			err:  "config.yaml:2: a profile must be a mapping",
+		},
+		{
+			name: "top level list",
+			file: "- work\n",
+			err:  "config.yaml:1: expected a mapping at the top level",
+		},
+		{
+			name: "invalid yaml",
+			file: "time_zone: [America/Toronto\n",
+			err:  "config.yaml: yaml: line 1",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseFile("config.yaml", []byte(tc.file), tc.profile)
+			if tc.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tc.err) {
+					t.Fatalf("expected an error starting with %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.profile != tc.selected {
+				t.Errorf("expected profile %q, got %q", tc.selected, c.profile)
+			}
+			if strings.Join(c.profiles, ",") != strings.Join(tc.profiles, ",") {
+				t.Errorf("expected profiles %v, got %v", tc.profiles, c.profiles)
+			}
+			b, err := json.Marshal(c.values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.values {
+				t.Errorf("expected values %s, got %s", tc.values, b)
+			}
+		})
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	testCases := []struct {
+		name   string
+		values string
+		env    []string
+		want   string
+		err    string
+	}{
+		{
+			name:   "top level",
+			values: `{"time_zone":"America/Toronto"}`,
+			env:    []string{"WHAT_IT_DO_TIME_ZONE=Europe/London"},
+			want:   `{"time_zone":"Europe/London"}`,
+		},
+		{
+			name:   "nested",
+			values: `{"calendar":{"project":"Meetings"}}`,
+			env:    []string{"WHAT_IT_DO_CALENDAR__OVERLAP_POLICY=split"},
+			want:   `{"calendar":{"overlap_policy":"split","project":"Meetings"}}`,
+		},
+		{
+			name:   "new section",
+			values: `{}`,
+			env:    []string{"WHAT_IT_DO_GIT__MAX_GAP=1h"},
+			want:   `{"git":{"max_gap":"1h"}}`,
+		},
+		{
+			name:   "null section",
+			values: `{"git":null}`,
+			env:    []string{"WHAT_IT_DO_GIT__MAX_GAP=1h"},
+			want:   `{"git":{"max_gap":"1h"}}`,
+		},
+		{
+			name:   "yaml values",
+			values: `{}`,
+			env:    []string{"WHAT_IT_DO_JIRA__VERIFY=true", "WHAT_IT_DO_GIT__REPOSITORIES=[~/a, ~/b]", "WHAT_IT_DO_HOURS=7.5"},
+			want:   `{"git":{"repositories":["~/a","~/b"]},"hours":7.5,"jira":{"verify":true}}`,
+		},
+		{
+			name:   "only flow lists are lists",
+			values: `{}`,
+			env:    []string{"WHAT_IT_DO_JIRA_KEY_PATTERN=[A-Z]+-\\d+"},
+			want:   `{"jira_key_pattern":"[A-Z]+-\\d+"}`,
+		},
+		{
+			name:   "ignored",
+			values: `{}`,
+			env:    []string{"WHAT_IT_DO_PROFILE=work", "WHAT_IT_DO_CONFIG_DIR=/tmp", "HOME=/root", "PATH"},
+			want:   `{}`,
+		},
+		{
+			name:   "map value",
+			values: `{}`,
+			env:    []string{"WHAT_IT_DO_CALENDAR={project: Meetings}"},
+			err:    "WHAT_IT_DO_CALENDAR: overrides must be a single value or a list",
+		},
+		{
+			name:   "scalar parent",
+			values: `{"time_zone":"America/Toronto"}`,
+			env:    []string{"WHAT_IT_DO_TIME_ZONE__NAME=Europe/London"},
+			err:    "WHAT_IT_DO_TIME_ZONE__NAME: time_zone is a single value, not a section",
+		},
+		{
+			name:   "nested scalar parent",
+			values: `{"calendar":{"project":"Meetings"}}`,
+			env:    []string{"WHAT_IT_DO_CALENDAR__PROJECT__NAME=Client"},
+			err:    "WHAT_IT_DO_CALENDAR__PROJECT__NAME: calendar.project is a single value, not a section",
+		},
+		{
+			name:   "replace section",
+			values: `{"calendar":{"project":"Meetings"}}`,
+			env:    []string{"WHAT_IT_DO_CALENDAR=Client"},
+			err:    "WHAT_IT_DO_CALENDAR: calendar is a section, override its values with __",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			values := map[string]any{}
+			err := json.Unmarshal([]byte(tc.values), &values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = applyEnv(values, tc.env)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := json.Marshal(values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, b)
+			}
+		})
+	}
+}
+
+type testSettings struct {
+	TimeZone string `json:"time_zone"`
+}
+
+type testCalendar struct {
+	Project string `json:"project"`
+}
+
+// useConfig writes config.yaml to a temporary config directory and registers
+// the test schemas.
+func useConfig(t *testing.T, file string) {
+	t.Helper()
+	dir := t.TempDir()
+	err := os.WriteFile(path.Join(dir, FileName), []byte(file), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldSchemas := schemas
+	schemas = map[string]func() any{}
+	RegisterSettings(func() any { return &testSettings{} })
+	RegisterSection("calendar", func() any { return &testCalendar{} })
+	SetDir(dir)
+	t.Cleanup(func() {
+		schemas = oldSchemas
+		SetDir("")
+		profile = ""
+	})
+}
+
+func TestValidate(t *testing.T) {
+	useConfig(t, `default_profile: work
+defaults:
+  time_zone: America/Toronto
+profiles:
+  work:
+    timezone: Europe/London
+    calendar:
+      project: Meetings
+      projects: Client
+  home: {}
+`)
+	err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Validate()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	dir := Dir(FileName)
+	want := []string{
+		dir + `:6: profile work: settings: unknown field "timezone"`,
+		dir + `:7: profile work: calendar: unknown field "projects"`,
+	}
+	got := strings.Split(err.Error(), "\n")
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected errors\n%s\ngot\n%s", strings.Join(want, "\n"), err)
+	}
+
+	err = Load("home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Validate()
+	if err != nil {
+		t.Errorf("expected the home profile to be valid, got %v", err)
+	}
+}
+
+func TestValidateEnv(t *testing.T) {
+	useConfig(t, "calendar:\n  project: Meetings\n")
+
+	t.Setenv("WHAT_IT_DO_CALENDAR__PROJECT", "[Client]")
+	err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Validate()
+	if err == nil || !strings.Contains(err.Error(), "calendar: cannot unmarshal array") {
+		t.Errorf("expected a type error, got %v", err)
+	}
+
+	t.Setenv("WHAT_IT_DO_CALENDAR__PROJECT", "Client")
+	t.Setenv("WHAT_IT_DO_CALENDAR__PROJECT__NAME", "Client")
+	err = Load("")
+	if err == nil || !strings.Contains(err.Error(), "calendar.project is a single value, not a section") {
+		t.Errorf("expected an error for the nested override, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package ezoauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultCallbackTimeout is how long the callback server waits for the
+// browser when the config doesn't set CallbackTimeout.
+const DefaultCallbackTimeout = 5 * time.Minute
+
+var (
+	// ErrCallbackTimeout is returned when the browser doesn't redirect back
+	// before the timeout.
+	ErrCallbackTimeout = errors.New("timed out waiting for the oauth callback")
+)
+
+// CallbackError is returned when the provider redirects back with an error,
+// e.g. access_denied when the user doesn't give consent.
+type CallbackError struct {
+	Code        string
+	Description string
+	URI         string
+}
+
+func (e *CallbackError) Error() string {
+	if e.Description == "" {
+		return "authorization failed: " + e.Code
+	}
+	return fmt.Sprintf("authorization failed: %s: %s", e.Code, e.Description)
+}
+
+// CallbackServer receives the authorization code on the redirect url.
+type CallbackServer struct {
+	state       string
+	ln          net.Listener
+	srv         *http.Server
+	redirectURL string
+
+	once   sync.Once
+	result chan callbackResult
+}
+
+type callbackResult struct {
+	code string
+	err  error
+}
+
+var _ http.Handler = (*CallbackServer)(nil)
+
+// NewCallbackServer listens on the port from redirectURL. If the port is 0
+// an ephemeral port is used, RedirectURL returns the url with the bound port.
+func NewCallbackServer(redirectURL, state string) (*CallbackServer, error) {
+	u, err := url.Parse(redirectURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redirect url: %w", err)
+	}
+	if u.Scheme != "http" {
+		return nil, fmt.Errorf("redirect url %s must use http to receive the callback locally", redirectURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+	}
+	// bind to the redirect url's host so a localhost callback, which
+	// receives the authorization code, isn't reachable from the network
+	ln, err := net.Listen("tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return nil, err
+	}
+	u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(ln.Addr().(*net.TCPAddr).Port))
+
+	s := newCallbackHandler(state)
+	s.ln = ln
+	s.redirectURL = u.String()
+	s.srv = &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		err := s.srv.Serve(ln)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.finish(callbackResult{err: err})
+		}
+	}()
+	return s, nil
+}
+
+func newCallbackHandler(state string) *CallbackServer {
+	return &CallbackServer{
+		state:  state,
+		result: make(chan callbackResult, 1),
+	}
+}
+
+// RedirectURL is the redirect url with the port the server is listening on.
+func (s *CallbackServer) RedirectURL() string {
+	return s.redirectURL
+}
+
+// ServeHTTP implements http.Handler.
+func (s *CallbackServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if !q.Has("code") && !q.Has("error") {
+		// the browser asking for /favicon.ico etc.
+		http.NotFound(w, r)
+		return
+	}
+	if q.Get("state") != s.state {
+		// a stale tab from an earlier attempt, keep waiting for the right one
+		slog.Warn("Ignoring oauth callback with the wrong state")
+		renderCallbackPage(w, http.StatusBadRequest, false, "The sign in link has expired. Start again from the terminal.")
+		return
+	}
+
+	if code := q.Get("error"); code != "" {
+		err := &CallbackError{
+			Code:        code,
+			Description: q.Get("error_description"),
+			URI:         q.Get("error_uri"),
+		}
+		renderCallbackPage(w, http.StatusOK, false, err.Error())
+		s.finish(callbackResult{err: err})
+		return
+	}
+
+	renderCallbackPage(w, http.StatusOK, true, "You are signed in, you can close this tab and continue in the terminal.")
+	s.finish(callbackResult{code: q.Get("code")})
+}
+
+func (s *CallbackServer) finish(r callbackResult) {
+	s.once.Do(func() {
+		s.result <- r
+	})
+}
+
+// Wait blocks until the provider redirects back, the timeout passes or ctx
+// is cancelled. The server is shut down before it returns.
+func (s *CallbackServer) Wait(ctx context.Context, timeout time.Duration) (string, error) {
+	defer s.Close()
+	if timeout <= 0 {
+		timeout = DefaultCallbackTimeout
+	}
+	ctx, cancel := context.WithTimeoutCause(ctx, timeout, ErrCallbackTimeout)
+	defer cancel()
+
+	select {
+	case r := <-s.result:
+		return r.code, r.err
+	case <-ctx.Done():
+		return "", context.Cause(ctx)
+	}
+}
+
+// Close shuts down the server, giving in flight responses a moment to finish.
+func (s *CallbackServer) Close() error {
+	if s.srv == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := s.srv.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return s.srv.Close()
+	}
+	return err
+}
+
+var callbackPage = template.Must(template.New("callback").Parse(`<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{if .Success}}Signed in{{else}}Sign in failed{{end}} - what-it-do</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 32em; margin: 4em auto; color: #222; }
+h1 { color: {{if .Success}}#2e7d32{{else}}#c62828{{end}}; }
+</style>
+</head>
+<body>
+<h1>{{if .Success}}Signed in{{else}}Sign in failed{{end}}</h1>
+<p>{{.Message}}</p>
+</body>
+</html>
+`))
+
+func renderCallbackPage(w http.ResponseWriter, status int, success bool, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	err := callbackPage.Execute(w, struct {
+		Success bool
+		Message string
+	}{success, message})
+	if err != nil {
+		slog.Warn("Failed to render oauth callback page", "err", err)
+	}
+}
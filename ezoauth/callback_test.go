@@ -0,0 +1,196 @@
+package ezoauth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCallbackHandler(t *testing.T) {
+	testCases := []struct {
+		name       string
+		query      string
+		status     int
+		body       string
+		done       bool
+		code       string
+		errCode    string
+		errMessage string
+	}{
+		{
+			name:   "success",
+			query:  "state=abc&code=1234",
+			status: http.StatusOK,
+			body:   "Signed in",
+			done:   true,
+			code:   "1234",
+		},
+		{
+			name:       "access denied",
+			query:      "state=abc&error=access_denied&error_description=The+user+denied+access",
+			status:     http.StatusOK,
+			body:       "The user denied access",
+			done:       true,
+			errCode:    "access_denied",
+			errMessage: "authorization failed: access_denied: The user denied access",
+		},
+		{
+			name:   "wrong state",
+			query:  "state=old&code=1234",
+			status: http.StatusBadRequest,
+			body:   "Sign in failed",
+		},
+		{
+			name:   "favicon",
+			query:  "",
+			status: http.StatusNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newCallbackHandler("abc")
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/callback?"+tc.query, nil))
+
+			if w.Code != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tc.body) {
+				t.Errorf("expected body to contain %q, got %q", tc.body, w.Body.String())
+			}
+
+			select {
+			case r := <-s.result:
+				if !tc.done {
+					t.Fatalf("expected no result, got %#v", r)
+				}
+				if r.code != tc.code {
+					t.Errorf("expected code %q, got %q", tc.code, r.code)
+				}
+				if tc.errCode == "" {
+					if r.err != nil {
+						t.Errorf("unexpected error: %v", r.err)
+					}
+					return
+				}
+				cbErr := &CallbackError{}
+				if !errors.As(r.err, &cbErr) {
+					t.Fatalf("expected a CallbackError, got %v", r.err)
+				}
+				if cbErr.Code != tc.errCode {
+					t.Errorf("expected error code %q, got %q", tc.errCode, cbErr.Code)
+				}
+				if cbErr.Error() != tc.errMessage {
+					t.Errorf("expected error %q, got %q", tc.errMessage, cbErr.Error())
+				}
+			default:
+				if tc.done {
+					t.Fatal("expected a result")
+				}
+			}
+		})
+	}
+}
+
+func TestCallbackHandler_only_first_result(t *testing.T) {
+	s := newCallbackHandler("abc")
+	for _, code := range []string{"1", "2"} {
+		s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/?state=abc&code="+code, nil))
+	}
+	r := <-s.result
+	if r.code != "1" {
+		t.Errorf("expected the first code, got %q", r.code)
+	}
+}
+
+func TestCallbackServer_ephemeral_port(t *testing.T) {
+	s, err := NewCallbackServer("http://127.0.0.1:0/callback", "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(s.RedirectURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Port() == "0" || u.Port() == "" {
+		t.Fatalf("expected a bound port, got %s", s.RedirectURL())
+	}
+	if u.Path != "/callback" {
+		t.Errorf("expected the path to be kept, got %s", u.Path)
+	}
+
+	go func() {
+		resp, err := http.Get(s.RedirectURL() + "?state=abc&code=1234")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+	}()
+
+	code, err := s.Wait(context.Background(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != "1234" {
+		t.Errorf("expected code 1234, got %q", code)
+	}
+}
+
+func TestCallbackServer_loopback(t *testing.T) {
+	for _, redirectURL := range []string{"http://127.0.0.1:0/", "http://localhost:0/", "http://[::1]:0/"} {
+		t.Run(redirectURL, func(t *testing.T) {
+			s, err := NewCallbackServer(redirectURL, "abc")
+			if err != nil && strings.Contains(redirectURL, "::1") {
+				t.Skipf("ipv6 isn't available: %v", err)
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			addr := s.ln.Addr().(*net.TCPAddr)
+			if !addr.IP.IsLoopback() {
+				t.Errorf("expected to listen on a loopback address, got %s", addr)
+			}
+		})
+	}
+}
+
+func TestCallbackServer_timeout(t *testing.T) {
+	s, err := NewCallbackServer("http://127.0.0.1:0/", "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Wait(context.Background(), 10*time.Millisecond)
+	if !errors.Is(err, ErrCallbackTimeout) {
+		t.Fatalf("expected ErrCallbackTimeout, got %v", err)
+	}
+}
+
+func TestCallbackServer_cancelled(t *testing.T) {
+	s, err := NewCallbackServer("http://127.0.0.1:0/", "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = s.Wait(ctx, time.Minute)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestNewCallbackServer_invalid_redirect(t *testing.T) {
+	_, err := NewCallbackServer("https://example.com/callback", "abc")
+	if err == nil {
+		t.Fatal("expected an error for an https redirect url")
+	}
+}
@@ -0,0 +1,116 @@
+package ezoauth
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// Flow selects how a new token is requested from the user.
+type Flow string
+
+const (
+	// FlowAuto opens a browser and listens on the redirect url, falling back
+	// to the device flow and then FlowManual when either isn't possible.
+	FlowAuto Flow = ""
+	// FlowBrowser opens a browser and listens on the redirect url.
+	FlowBrowser Flow = "browser"
+	// FlowDevice uses the RFC 8628 device authorization grant, the endpoint
+	// must have a DeviceAuthURL.
+	FlowDevice Flow = "device"
+	// FlowManual prints the auth url and asks the user to paste the url they
+	// were redirected to.
+	FlowManual Flow = "manual"
+)
+
+// DefaultFlow is used by configs without a Flow.
+var DefaultFlow = FlowAuto
+
+// ParseFlow returns the flow with the given name.
+func ParseFlow(s string) (Flow, error) {
+	switch f := Flow(s); f {
+	case FlowAuto, FlowBrowser, FlowDevice, FlowManual:
+		return f, nil
+	case "auto":
+		return FlowAuto, nil
+	}
+	return "", fmt.Errorf("unknown auth flow %q, must be one of auto, %s, %s or %s", s, FlowBrowser, FlowDevice, FlowManual)
+}
+
+var errNoBrowser = errors.New("no browser or callback port available")
+
+func (c *Config) flow() Flow {
+	if c.Flow != FlowAuto {
+		return c.Flow
+	}
+	return DefaultFlow
+}
+
+func (c *Config) supportsDeviceFlow() bool {
+	return c.OAuthConfig.Endpoint.DeviceAuthURL != ""
+}
+
+// getTokenFromDevice runs the device authorization grant, printing the code
+// the user has to enter and polling until they do.
+func (c *Config) getTokenFromDevice(ctx context.Context) (*oauth2.Token, error) {
+	if !c.supportsDeviceFlow() {
+		return nil, fmt.Errorf("%s does not support the device flow", c.Name)
+	}
+	resp, err := c.OAuthConfig.DeviceAuth(ctx, c.AuthCodeURLOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("device authorization failed: %w", err)
+	}
+
+	if resp.VerificationURIComplete != "" {
+		fmt.Fprintf(os.Stderr, "Go to the following link to sign in to %s:\n%v\n", c.Name, resp.VerificationURIComplete)
+	} else {
+		fmt.Fprintf(os.Stderr, "Go to the following link to sign in to %s:\n%v\n", c.Name, resp.VerificationURI)
+	}
+	fmt.Fprintf(os.Stderr, "and enter the code %s\n", resp.UserCode)
+
+	tok, err := c.OAuthConfig.DeviceAccessToken(ctx, resp, c.ExchangeOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("device access token: %w", err)
+	}
+	return tok, nil
+}
+
+// readRedirectURL asks the user to paste the url the provider redirected them
+// to and returns the code from it.
+func readRedirectURL(state string) (string, error) {
+	fmt.Fprintf(os.Stderr, "After approving, paste the full url you were redirected to (it may fail to load):\n> ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read redirect url: %w", err)
+	}
+	return codeFromRedirectURL(strings.TrimSpace(line), state)
+}
+
+func codeFromRedirectURL(rawURL, state string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid redirect url: %w", err)
+	}
+	q := u.Query()
+	if q.Get("state") != state {
+		return "", fmt.Errorf("redirect url state does not match")
+	}
+	if e := q.Get("error"); e != "" {
+		return "", &CallbackError{
+			Code:        e,
+			Description: q.Get("error_description"),
+			URI:         q.Get("error_uri"),
+		}
+	}
+	code := q.Get("code")
+	if code == "" {
+		return "", fmt.Errorf("redirect url has no code")
+	}
+	return code, nil
+}
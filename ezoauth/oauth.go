@@ -5,18 +5,18 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
+	"slices"
 	"time"
 
-	"github.com/abibby/what-it-do/config"
 	"github.com/abibby/what-it-do/open"
 	"golang.org/x/oauth2"
 )
@@ -26,8 +26,47 @@ type Config struct {
 	OAuthConfig     *oauth2.Config
 	AuthCodeURLOpts []oauth2.AuthCodeOption
 	ExchangeOpts    []oauth2.AuthCodeOption
+	// Store saves the token between runs, DefaultStore is used if it is nil.
+	Store TokenStore
+	// Flow is how new tokens are requested, DefaultFlow is used if it is
+	// FlowAuto.
+	Flow Flow
+	// EphemeralPort listens on any free port instead of the port in the
+	// redirect url, for providers that accept any port on loopback redirects.
+	EphemeralPort bool
+	// CallbackTimeout is how long to wait for the browser to redirect back,
+	// defaults to DefaultCallbackTimeout.
+	CallbackTimeout time.Duration
+	// NoPrompt makes Token return ErrLoginRequired instead of asking the user
+	// to sign in.
+	NoPrompt bool
+	// DisablePKCE stops the auth code flow from sending a PKCE challenge for
+	// providers that reject it.
+	DisablePKCE bool
 }
 
+// ErrLoginRequired is returned by Token with NoPrompt set when there is no
+// usable token.
+var ErrLoginRequired = errors.New("login required")
+
+func (c *Config) store() TokenStore {
+	if c.Store != nil {
+		return c.Store
+	}
+	return defaultStore()
+}
+
+// WrapTransport, if set, wraps the transport under every LogRoundTripper made
+// by NewLogRoundTripper. It is used to cache responses.
+var WrapTransport func(service string, rt http.RoundTripper) http.RoundTripper
+
+// Offline stops Client from loading or refreshing tokens, its requests fail
+// with ErrOffline unless WrapTransport answers them.
+var Offline bool
+
+// ErrOffline is returned by the clients from Client when Offline is set.
+var ErrOffline = errors.New("offline")
+
 type LogRoundTripper struct {
 	Service   string
 	Transport http.RoundTripper
@@ -35,6 +74,15 @@ type LogRoundTripper struct {
 
 var _ http.RoundTripper = (*LogRoundTripper)(nil)
 
+// NewLogRoundTripper logs the requests sent to service through rt, wrapped by
+// WrapTransport.
+func NewLogRoundTripper(service string, rt http.RoundTripper) *LogRoundTripper {
+	if WrapTransport != nil {
+		rt = WrapTransport(service, rt)
+	}
+	return &LogRoundTripper{Transport: rt, Service: service}
+}
+
 // RoundTrip implements http.RoundTripper.
 func (l *LogRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	slog.Debug("Authenticated request", "service", l.Service, "url", req.URL, "method", req.Method)
@@ -42,27 +90,34 @@ func (l *LogRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 }
 
 func (c *Config) Token(ctx context.Context) (*oauth2.Token, error) {
-	// The file token.json stores the user's access and refresh tokens, and is
-	// created automatically when the authorization flow completes for the first
+	// The store holds the user's access and refresh tokens, the token is
+	// saved automatically when the authorization flow completes for the first
 	// time.
-	tokFile := path.Join(config.Dir(c.Name + "_token.json"))
-	tok, err := tokenFromFile(tokFile)
-	if err != nil {
+	store := c.store()
+	tok, err := store.Load(c.Name)
+	if errors.Is(err, ErrTokenNotFound) {
+		if c.NoPrompt {
+			return nil, fmt.Errorf("%w: %s", ErrLoginRequired, c.Name)
+		}
 		tok, err = c.getTokenFromWeb(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get token from web: %w", err)
 		}
-		err = saveToken(tokFile, tok)
+		err = store.Save(c.Name, tok)
 		if err != nil {
 			return nil, fmt.Errorf("failed to save token: %w", err)
 		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load token: %w", err)
 	}
 
 	if !tok.Valid() {
 		slog.Info("Refreshing oauth token", "service", c.Name)
 
 		refreshed, err := c.OAuthConfig.TokenSource(ctx, tok).Token()
-		if err != nil {
+		if err != nil && c.NoPrompt {
+			return nil, fmt.Errorf("%w: failed to refresh %s token: %w", ErrLoginRequired, c.Name, err)
+		} else if err != nil {
 			slog.Warn("Failed to refresh access token", "err", err)
 			refreshed, err = c.getTokenFromWeb(ctx)
 			if err != nil {
@@ -71,8 +126,8 @@ func (c *Config) Token(ctx context.Context) (*oauth2.Token, error) {
 		}
 
 		if !tokensEqual(tok, refreshed) {
-			tok = refreshed
-			err = saveToken(tokFile, tok)
+			tok = keepScopes(tok, refreshed)
+			err = store.Save(c.Name, tok)
 			if err != nil {
 				return nil, fmt.Errorf("failed to save token: %w", err)
 			}
@@ -80,17 +135,52 @@ func (c *Config) Token(ctx context.Context) (*oauth2.Token, error) {
 	}
 	return tok, nil
 }
+
+// Login asks the user to sign in even if there is a saved token and saves the
+// new token.
+func (c *Config) Login(ctx context.Context) (*oauth2.Token, error) {
+	tok, err := c.getTokenFromWeb(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token from web: %w", err)
+	}
+	err = c.store().Save(c.Name, tok)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save token: %w", err)
+	}
+	return tok, nil
+}
+
+// Logout deletes the saved token.
+func (c *Config) Logout() error {
+	return c.store().Delete(c.Name)
+}
+
+// SavedToken returns the saved token without refreshing it or asking the user
+// to sign in.
+func (c *Config) SavedToken() (*oauth2.Token, error) {
+	return c.store().Load(c.Name)
+}
+
 func (c *Config) Client(ctx context.Context) (*http.Client, error) {
-	tok, err := c.Token(ctx)
+	if Offline {
+		return &http.Client{Transport: NewLogRoundTripper(c.Name, offlineTransport{})}, nil
+	}
+	ts, err := c.TokenSource(ctx)
 	if err != nil {
 		return nil, err
 	}
-	client := oauth2.NewClient(ctx, oauth2.StaticTokenSource(tok))
+	client := oauth2.NewClient(ctx, ts)
 
-	client.Transport = &LogRoundTripper{Transport: client.Transport, Service: c.Name}
+	client.Transport = NewLogRoundTripper(c.Name, client.Transport)
 	return client, nil
 }
 
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("%w: %s %s", ErrOffline, req.Method, req.URL)
+}
+
 func newState() (string, error) {
 	b := make([]byte, 32)
 	_, err := rand.Reader.Read(b)
@@ -107,24 +197,88 @@ func newState() (string, error) {
 
 // Request a token from the web, then returns the retrieved token.
 func (c *Config) getTokenFromWeb(ctx context.Context) (*oauth2.Token, error) {
+	switch c.flow() {
+	case FlowDevice:
+		return c.getTokenFromDevice(ctx)
+	case FlowManual:
+		return c.getTokenFromAuthCode(ctx, false)
+	case FlowBrowser:
+		return c.getTokenFromAuthCode(ctx, true)
+	}
+
+	tok, err := c.getTokenFromAuthCode(ctx, true)
+	if !errors.Is(err, errNoBrowser) {
+		return tok, err
+	}
+	slog.Warn("Could not use a browser to sign in", "service", c.Name, "err", err)
+	if c.supportsDeviceFlow() {
+		return c.getTokenFromDevice(ctx)
+	}
+	return c.getTokenFromAuthCode(ctx, false)
+}
+
+// getTokenFromAuthCode runs the auth code flow. With browser set the auth url
+// is opened and the code is received on the redirect url, otherwise the user
+// pastes the redirect url into the terminal.
+func (c *Config) getTokenFromAuthCode(ctx context.Context, browser bool) (*oauth2.Token, error) {
 	state, err := newState()
 	if err != nil {
 		return nil, err
 	}
 
-	authURL := c.OAuthConfig.AuthCodeURL(state, c.AuthCodeURLOpts...)
-	fmt.Fprintf(os.Stderr, "Go to the following link in your browser: \n%v\n", authURL)
-	err = open.URL(authURL)
-	if err != nil {
-		return nil, err
+	authOpts := c.AuthCodeURLOpts
+	exchangeOpts := c.ExchangeOpts
+	if !c.DisablePKCE {
+		verifier := oauth2.GenerateVerifier()
+		authOpts = append(slices.Clip(authOpts), oauth2.S256ChallengeOption(verifier))
+		exchangeOpts = append(slices.Clip(exchangeOpts), oauth2.VerifierOption(verifier))
 	}
 
-	authCode, err := runCodePullServer(c.OAuthConfig, state)
-	if err != nil {
-		return nil, fmt.Errorf("code retrieval server failed: %w", err)
+	oauthConfig := c.OAuthConfig
+	var authCode string
+	if browser {
+		if !open.CanOpen() {
+			return nil, errNoBrowser
+		}
+		redirectURL := c.OAuthConfig.RedirectURL
+		if c.EphemeralPort {
+			redirectURL, err = withPort(redirectURL, "0")
+			if err != nil {
+				return nil, err
+			}
+		}
+		srv, err := NewCallbackServer(redirectURL, state)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errNoBrowser, err)
+		}
+		defer srv.Close()
+
+		// the redirect url has to match in the auth url and the exchange
+		cfg := *c.OAuthConfig
+		cfg.RedirectURL = srv.RedirectURL()
+		oauthConfig = &cfg
+
+		authURL := oauthConfig.AuthCodeURL(state, authOpts...)
+		err = open.URL(authURL)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errNoBrowser, err)
+		}
+		fmt.Fprintf(os.Stderr, "Go to the following link in your browser: \n%v\n", authURL)
+
+		authCode, err = srv.Wait(ctx, c.CallbackTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("code retrieval server failed: %w", err)
+		}
+	} else {
+		authURL := oauthConfig.AuthCodeURL(state, authOpts...)
+		fmt.Fprintf(os.Stderr, "Go to the following link in your browser: \n%v\n", authURL)
+		authCode, err = readRedirectURL(state)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	tok, err := c.OAuthConfig.Exchange(ctx, authCode, c.ExchangeOpts...)
+	tok, err := oauthConfig.Exchange(ctx, authCode, exchangeOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to complete token exchange: %w", err)
 	}
@@ -139,9 +293,11 @@ func tokenFromFile(file string) (*oauth2.Token, error) {
 		return nil, err
 	}
 	defer f.Close()
-	tok := &oauth2.Token{}
-	err = json.NewDecoder(f).Decode(tok)
-	return tok, err
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalToken(b)
 }
 
 // Saves a token to a file path.
@@ -158,50 +314,21 @@ func saveToken(p string, token *oauth2.Token) error {
 		return fmt.Errorf("unable to cache oauth token: %v", err)
 	}
 	defer f.Close()
-	err = json.NewEncoder(f).Encode(token)
+	b, err := marshalToken(token)
 	if err != nil {
 		return fmt.Errorf("failed to encode token: %w", err)
 	}
-	return nil
+	_, err = f.Write(b)
+	return err
 }
 
-func runCodePullServer(config *oauth2.Config, state string) (string, error) {
-	redirectURL, err := url.Parse(config.RedirectURL)
+func withPort(rawURL, port string) (string, error) {
+	u, err := url.Parse(rawURL)
 	if err != nil {
-		log.Fatal(err)
-	}
-
-	authCode := ""
-	var s *http.Server
-	s = &http.Server{
-		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			q := r.URL.Query()
-			if !q.Has("code") {
-				return
-			}
-			if q.Get("state") != state {
-				w.WriteHeader(401)
-				return
-			}
-			authCode = q.Get("code")
-
-			fmt.Fprintf(w, "Continue in the terminal")
-
-			go func() {
-				time.Sleep(20 * time.Millisecond)
-				s.Close()
-			}()
-		}),
-		Addr: ":" + redirectURL.Port(),
-	}
-
-	err = s.ListenAndServe()
-	if errors.Is(err, http.ErrServerClosed) {
-		// no-op
-	} else if err != nil {
-		return "", err
+		return "", fmt.Errorf("invalid redirect url: %w", err)
 	}
-	return authCode, nil
+	u.Host = net.JoinHostPort(u.Hostname(), port)
+	return u.String(), nil
 }
 
 func tokensEqual(a, b *oauth2.Token) bool {
@@ -0,0 +1,49 @@
+package ezoauth
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// PassphraseEnv is checked for the encrypted token store passphrase before
+// prompting.
+const PassphraseEnv = "WHAT_IT_DO_TOKEN_PASSPHRASE"
+
+// PromptPassphrase returns the passphrase from PassphraseEnv or asks for it on
+// the terminal with echo disabled.
+func PromptPassphrase() ([]byte, error) {
+	if p := os.Getenv(PassphraseEnv); p != "" {
+		return []byte(p), nil
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("no terminal to prompt for a passphrase, set %s: %w", PassphraseEnv, err)
+	}
+	defer tty.Close()
+
+	fmt.Fprint(tty, "Token store passphrase: ")
+	err = stty(tty, "-echo")
+	if err != nil {
+		return nil, fmt.Errorf("could not disable echo, set %s: %w", PassphraseEnv, err)
+	}
+	defer func() {
+		_ = stty(tty, "echo")
+		fmt.Fprintln(tty)
+	}()
+
+	line, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.TrimRight(line, "\r\n")), nil
+}
+
+func stty(tty *os.File, arg string) error {
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = tty
+	return cmd.Run()
+}
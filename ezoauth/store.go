@@ -0,0 +1,199 @@
+package ezoauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/abibby/what-it-do/config"
+	"golang.org/x/oauth2"
+)
+
+// ErrTokenNotFound is returned by TokenStore.Load when there is no token
+// saved for the name.
+var ErrTokenNotFound = errors.New("token not found")
+
+// TokenStore persists oauth tokens between runs.
+type TokenStore interface {
+	// Load returns the token saved for name or an error wrapping
+	// ErrTokenNotFound.
+	Load(name string) (*oauth2.Token, error)
+	Save(name string, token *oauth2.Token) error
+	Delete(name string) error
+}
+
+// DefaultStore is used by configs without a Store. If it is nil tokens are
+// saved in config.TokenDir, the directories are looked up when a token is
+// used so they follow config.SetDir.
+var DefaultStore TokenStore
+
+func defaultStore() TokenStore {
+	if DefaultStore == nil {
+		return NewFileStore(config.TokenDir(), config.Dir())
+	}
+	return DefaultStore
+}
+
+// FileStore saves tokens as plaintext <name>_token.json files.
+type FileStore struct {
+	dir        string
+	legacyDirs []string
+}
+
+var _ TokenStore = (*FileStore)(nil)
+
+// NewFileStore saves tokens in dir. Tokens found in legacyDirs are moved into
+// dir the first time they are loaded.
+func NewFileStore(dir string, legacyDirs ...string) *FileStore {
+	return &FileStore{dir: dir, legacyDirs: legacyDirs}
+}
+
+func (s *FileStore) path(name string) string {
+	return path.Join(s.dir, name+"_token.json")
+}
+
+func (s *FileStore) Load(name string) (*oauth2.Token, error) {
+	tok, err := tokenFromFile(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		tok, err = s.loadLegacy(name)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %w", ErrTokenNotFound, err)
+	}
+	return tok, err
+}
+
+func (s *FileStore) loadLegacy(name string) (*oauth2.Token, error) {
+	for _, dir := range s.legacyDirs {
+		p := path.Join(dir, name+"_token.json")
+		tok, err := tokenFromFile(p)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		err = s.Save(name, tok)
+		if err != nil {
+			return nil, err
+		}
+		err = os.Remove(p)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Moved token", "from", p, "to", s.path(name))
+		return tok, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+func (s *FileStore) Save(name string, token *oauth2.Token) error {
+	return saveToken(s.path(name), token)
+}
+
+func (s *FileStore) Delete(name string) error {
+	for _, dir := range append([]string{s.dir}, s.legacyDirs...) {
+		err := os.Remove(path.Join(dir, name+"_token.json"))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the names of the saved tokens, including the ones still in
+// the legacy directories.
+func (s *FileStore) List() ([]string, error) {
+	names := []string{}
+	for _, dir := range append([]string{s.dir}, s.legacyDirs...) {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			name, ok := strings.CutSuffix(e.Name(), "_token.json")
+			if ok && !e.IsDir() && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// MigrateTokens moves every token in from into to, deleting the plaintext
+// files once they are saved. It returns the names of the migrated tokens.
+func MigrateTokens(from *FileStore, to TokenStore) ([]string, error) {
+	names, err := from.List()
+	if err != nil {
+		return nil, err
+	}
+	migrated := []string{}
+	for _, name := range names {
+		tok, err := from.Load(name)
+		if err != nil {
+			return migrated, fmt.Errorf("load %s token: %w", name, err)
+		}
+		err = to.Save(name, tok)
+		if err != nil {
+			return migrated, fmt.Errorf("save %s token: %w", name, err)
+		}
+		err = from.Delete(name)
+		if err != nil {
+			return migrated, fmt.Errorf("delete %s token file: %w", name, err)
+		}
+		slog.Info("Migrated token", "name", name)
+		migrated = append(migrated, name)
+	}
+	return migrated, nil
+}
+
+// storedToken adds the granted scopes to the saved token, oauth2.Token only
+// keeps them in its unexported raw response.
+type storedToken struct {
+	*oauth2.Token
+	Scope string `json:"scope,omitempty"`
+}
+
+func marshalToken(token *oauth2.Token) ([]byte, error) {
+	return json.Marshal(&storedToken{
+		Token: token,
+		Scope: strings.Join(Scopes(token), " "),
+	})
+}
+
+func unmarshalToken(b []byte) (*oauth2.Token, error) {
+	st := &storedToken{Token: &oauth2.Token{}}
+	err := json.Unmarshal(b, st)
+	if err != nil {
+		return nil, err
+	}
+	if st.Scope == "" {
+		return st.Token, nil
+	}
+	return st.Token.WithExtra(map[string]any{"scope": st.Scope}), nil
+}
+
+// Scopes returns the scopes the provider granted with the token. It is empty
+// if the provider didn't say.
+func Scopes(token *oauth2.Token) []string {
+	scope, _ := token.Extra("scope").(string)
+	// github separates scopes with commas
+	return strings.FieldsFunc(scope, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+}
+
+// keepScopes copies the scopes from old to a refreshed token when the refresh
+// response doesn't include them.
+func keepScopes(old, refreshed *oauth2.Token) *oauth2.Token {
+	if len(Scopes(refreshed)) > 0 || len(Scopes(old)) == 0 {
+		return refreshed
+	}
+	return refreshed.WithExtra(map[string]any{"scope": strings.Join(Scopes(old), " ")})
+}
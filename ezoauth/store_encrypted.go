@@ -0,0 +1,153 @@
+package ezoauth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/oauth2"
+)
+
+// EncryptedFileStore saves tokens in <name>_token.enc files encrypted with
+// AES-GCM using a key derived from a passphrase with scrypt.
+type EncryptedFileStore struct {
+	dir        string
+	passphrase func() ([]byte, error)
+
+	mtx    sync.Mutex
+	cached []byte
+}
+
+var _ TokenStore = (*EncryptedFileStore)(nil)
+
+// NewEncryptedFileStore creates a store in dir. passphrase is called the
+// first time a token is read or written.
+func NewEncryptedFileStore(dir string, passphrase func() ([]byte, error)) *EncryptedFileStore {
+	return &EncryptedFileStore{
+		dir:        dir,
+		passphrase: passphrase,
+	}
+}
+
+type encryptedToken struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func (s *EncryptedFileStore) path(name string) string {
+	return path.Join(s.dir, name+"_token.enc")
+}
+
+func (s *EncryptedFileStore) getPassphrase() ([]byte, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.cached != nil {
+		return s.cached, nil
+	}
+	p, err := s.passphrase()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token passphrase: %w", err)
+	}
+	if len(p) == 0 {
+		return nil, fmt.Errorf("token passphrase is empty")
+	}
+	s.cached = p
+	return p, nil
+}
+
+func (s *EncryptedFileStore) gcm(salt []byte) (cipher.AEAD, error) {
+	passphrase, err := s.getPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key(passphrase, salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *EncryptedFileStore) Load(name string) (*oauth2.Token, error) {
+	b, err := os.ReadFile(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %w", ErrTokenNotFound, err)
+	} else if err != nil {
+		return nil, err
+	}
+
+	enc := &encryptedToken{}
+	err = json.Unmarshal(b, enc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encrypted token file: %w", err)
+	}
+	if enc.Version != 1 {
+		return nil, fmt.Errorf("unsupported encrypted token version %d", enc.Version)
+	}
+
+	gcm, err := s.gcm(enc.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, enc.Nonce, enc.Ciphertext, []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s token, is the passphrase correct?", name)
+	}
+	return unmarshalToken(plain)
+}
+
+func (s *EncryptedFileStore) Save(name string, token *oauth2.Token) error {
+	plain, err := marshalToken(token)
+	if err != nil {
+		return err
+	}
+
+	enc := &encryptedToken{
+		Version: 1,
+		Salt:    make([]byte, 16),
+	}
+	_, err = rand.Read(enc.Salt)
+	if err != nil {
+		return err
+	}
+	gcm, err := s.gcm(enc.Salt)
+	if err != nil {
+		return err
+	}
+	enc.Nonce = make([]byte, gcm.NonceSize())
+	_, err = rand.Read(enc.Nonce)
+	if err != nil {
+		return err
+	}
+	enc.Ciphertext = gcm.Seal(nil, enc.Nonce, plain, []byte(name))
+
+	b, err := json.Marshal(enc)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(s.dir, 0755)
+	if err != nil {
+		return fmt.Errorf("unable to create directory for oauth token: %w", err)
+	}
+	return os.WriteFile(s.path(name), b, 0600)
+}
+
+func (s *EncryptedFileStore) Delete(name string) error {
+	err := os.Remove(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,79 @@
+package ezoauth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// KeyringStore saves tokens in the Secret Service keyring (GNOME Keyring,
+// KWallet) using the secret-tool command from libsecret.
+type KeyringStore struct {
+	service string
+}
+
+var _ TokenStore = (*KeyringStore)(nil)
+
+// NewKeyringStore returns an error if the Secret Service can't be used on
+// this system.
+func NewKeyringStore(service string) (*KeyringStore, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("the keyring token store is only supported on linux")
+	}
+	_, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return nil, fmt.Errorf("the keyring token store requires secret-tool from libsecret: %w", err)
+	}
+	return &KeyringStore{service: service}, nil
+}
+
+func (s *KeyringStore) attributes(name string) []string {
+	return []string{"service", s.service, "account", name}
+}
+
+func (s *KeyringStore) Load(name string) (*oauth2.Token, error) {
+	out, err := s.secretTool(nil, append([]string{"lookup"}, s.attributes(name)...)...)
+	if err != nil {
+		// secret-tool exits with 1 and no output when nothing matches
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && len(out) == 0 {
+			return nil, fmt.Errorf("%w: %s not in keyring", ErrTokenNotFound, name)
+		}
+		return nil, err
+	}
+	return unmarshalToken(out)
+}
+
+func (s *KeyringStore) Save(name string, token *oauth2.Token) error {
+	b, err := marshalToken(token)
+	if err != nil {
+		return err
+	}
+	args := append([]string{"store", "--label=" + s.service + " " + name + " token"}, s.attributes(name)...)
+	_, err = s.secretTool(b, args...)
+	return err
+}
+
+func (s *KeyringStore) Delete(name string) error {
+	_, err := s.secretTool(nil, append([]string{"clear"}, s.attributes(name)...)...)
+	return err
+}
+
+func (s *KeyringStore) secretTool(stdin []byte, args ...string) ([]byte, error) {
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command("secret-tool", args...)
+	cmd.Stderr = stderr
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	out, err := cmd.Output()
+	if err != nil && stderr.Len() > 0 {
+		return out, fmt.Errorf("secret-tool %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, err
+}
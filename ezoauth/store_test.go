@@ -0,0 +1,36 @@
+package ezoauth
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/abibby/what-it-do/config"
+)
+
+func TestDefaultStoreFollowsConfigDir(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	config.SetDir(configDir)
+	t.Cleanup(func() { config.SetDir("") })
+
+	legacy := path.Join(configDir, "google_token.json")
+	err := os.WriteFile(legacy, []byte(`{"access_token":"abc","token_type":"Bearer"}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := defaultStore().Load("google")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "abc" {
+		t.Errorf("expected the legacy token, got %q", tok.AccessToken)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("expected the legacy token to be moved, got %v", err)
+	}
+	if _, err := os.Stat(path.Join(config.TokenDir(), "google_token.json")); err != nil {
+		t.Errorf("expected the token in the token dir: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package ezoauth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// persistingTokenSource refreshes the token when it expires and saves the
+// result to the store, refresh tokens are rotated by some providers
+// (Atlassian) so the old one stops working once it is used.
+type persistingTokenSource struct {
+	ctx    context.Context
+	config *Config
+
+	mtx sync.Mutex
+	tok *oauth2.Token
+}
+
+var _ oauth2.TokenSource = (*persistingTokenSource)(nil)
+
+// TokenSource returns a token source that starts from Token and refreshes on
+// demand, saving refreshed tokens to the store. It is safe for concurrent use.
+func (c *Config) TokenSource(ctx context.Context) (oauth2.TokenSource, error) {
+	tok, err := c.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &persistingTokenSource{
+		ctx:    ctx,
+		config: c,
+		tok:    tok,
+	}, nil
+}
+
+// Token implements oauth2.TokenSource.
+func (s *persistingTokenSource) Token() (*oauth2.Token, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.tok.Valid() {
+		return s.tok, nil
+	}
+
+	store := s.config.store()
+
+	// another process may have already used and rotated the refresh token
+	stored, err := store.Load(s.config.Name)
+	if err == nil && stored.Valid() {
+		s.tok = stored
+		return s.tok, nil
+	} else if err == nil && stored.RefreshToken != "" {
+		s.tok = stored
+	}
+
+	slog.Info("Refreshing oauth token", "service", s.config.Name)
+	refreshed, err := s.config.OAuthConfig.TokenSource(s.ctx, s.tok).Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh %s token: %w", s.config.Name, err)
+	}
+
+	refreshed = keepScopes(s.tok, refreshed)
+	if !tokensEqual(s.tok, refreshed) {
+		err = store.Save(s.config.Name, refreshed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save token: %w", err)
+		}
+	}
+	s.tok = refreshed
+	return s.tok, nil
+}
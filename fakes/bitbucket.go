@@ -0,0 +1,151 @@
+package fakes
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/abibby/what-it-do/bitbucket"
+)
+
+var (
+	stateRE    = regexp.MustCompile(`state="(\w+)"`)
+	followerRE = regexp.MustCompile(`followers\.uuid="([^"]+)"`)
+	afterRE    = regexp.MustCompile(`updated_on > (\S+)`)
+	beforeRE   = regexp.MustCompile(`updated_on < (\S+)`)
+)
+
+func (srv *Server) handleBitbucket(mux *http.ServeMux) {
+	mux.HandleFunc("GET /2.0/user", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, srv.bitbucketAccount(srv.me))
+	})
+	// the q parameter is matched on state, followers and updated_on, the
+	// other conditions are ignored
+	mux.HandleFunc("GET /!api/internal/workspaces/{workspace}/pullrequests/", func(w http.ResponseWriter, r *http.Request) {
+		if srv.noInternalAPI {
+			writeError(w, http.StatusNotFound, "fakes: the internal api is disabled")
+			return
+		}
+		srv.writePullRequests(w, r, "")
+	})
+	mux.HandleFunc("GET /2.0/repositories/{workspace}", func(w http.ResponseWriter, r *http.Request) {
+		repos := []*bitbucket.Repository{}
+		for _, pr := range srv.prs {
+			if !slices.ContainsFunc(repos, func(repo *bitbucket.Repository) bool {
+				return repo.Slug == pr.repository
+			}) {
+				repos = append(repos, srv.bitbucketRepository(r.PathValue("workspace"), pr.repository))
+			}
+		}
+		writePage(w, repos)
+	})
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/pullrequests", func(w http.ResponseWriter, r *http.Request) {
+		srv.writePullRequests(w, r, r.PathValue("repo"))
+	})
+}
+
+// writePullRequests writes the pull requests matching the q parameter, only
+// the ones in repo if it is set.
+func (srv *Server) writePullRequests(w http.ResponseWriter, r *http.Request, repo string) {
+	q := r.URL.Query().Get("q")
+	prs := []*bitbucket.PullRequest{}
+	for _, pr := range srv.prs {
+		if (repo == "" || pr.repository == repo) && srv.matchPullRequest(pr, q) {
+			prs = append(prs, srv.bitbucketPullRequest(pr))
+		}
+	}
+	writePage(w, prs)
+}
+
+func writePage[T any](w http.ResponseWriter, values []T) {
+	writeJSON(w, &bitbucket.PaginatedResponse[T]{
+		Size:    len(values),
+		Page:    1,
+		PageLen: max(len(values), 1),
+		Values:  values,
+	})
+}
+
+func (srv *Server) matchPullRequest(pr *pullRequest, q string) bool {
+	state := pr.State
+	if state == "" {
+		state = "OPEN"
+	}
+	if states := stateRE.FindAllStringSubmatch(q, -1); len(states) > 0 && !slices.ContainsFunc(states, func(m []string) bool {
+		return m[1] == state
+	}) {
+		return false
+	}
+	if m := followerRE.FindStringSubmatch(q); m != nil {
+		follower := m[1] == srv.bitbucketAccount(pr.author).UUID
+		for _, p := range pr.participants {
+			follower = follower || m[1] == srv.bitbucketAccount(p.user).UUID
+		}
+		if !follower {
+			return false
+		}
+	}
+	if m := afterRE.FindStringSubmatch(q); m != nil {
+		after, err := time.Parse(time.RFC3339, m[1])
+		if err == nil && !pr.updated.After(after) {
+			return false
+		}
+	}
+	if m := beforeRE.FindStringSubmatch(q); m != nil {
+		before, err := time.Parse(time.RFC3339, m[1])
+		if err == nil && !pr.updated.Before(before) {
+			return false
+		}
+	}
+	return true
+}
+
+func (srv *Server) bitbucketAccount(email string) *bitbucket.Account {
+	return &bitbucket.Account{
+		Type:        "user",
+		DisplayName: srv.name(email),
+		UUID:        "{" + id(email) + "}",
+	}
+}
+
+func (srv *Server) bitbucketRepository(workspace, slug string) *bitbucket.Repository {
+	return &bitbucket.Repository{
+		UUID:     "{" + id(workspace+"/"+slug) + "}",
+		FullName: workspace + "/" + slug,
+		Slug:     slug,
+		Name:     slug,
+	}
+}
+
+func (srv *Server) bitbucketPullRequest(pr *pullRequest) *bitbucket.PullRequest {
+	state := pr.State
+	if state == "" {
+		state = "OPEN"
+	}
+	result := &bitbucket.PullRequest{
+		ID:           pr.id,
+		Title:        pr.Title,
+		State:        state,
+		Author:       srv.bitbucketAccount(pr.author),
+		Reviewers:    []*bitbucket.Account{},
+		Participants: []*bitbucket.Participant{},
+	}
+	for _, p := range pr.participants {
+		role := p.Role
+		if role == "" {
+			role = "REVIEWER"
+		}
+		account := srv.bitbucketAccount(p.user)
+		if role == "REVIEWER" {
+			result.Reviewers = append(result.Reviewers, account)
+		}
+		result.Participants = append(result.Participants, &bitbucket.Participant{
+			User:           account,
+			Role:           role,
+			Approved:       p.Approved,
+			ParticipatedOn: p.at.Format(time.RFC3339),
+		})
+	}
+	return result
+}
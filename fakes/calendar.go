@@ -0,0 +1,92 @@
+package fakes
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+func (srv *Server) handleCalendar(mux *http.ServeMux) {
+	mux.HandleFunc("GET /calendar/v3/calendars/{calendar}/events", func(w http.ResponseWriter, r *http.Request) {
+		calendarID := r.PathValue("calendar")
+		timeMin, minErr := time.Parse(time.RFC3339, r.URL.Query().Get("timeMin"))
+		timeMax, maxErr := time.Parse(time.RFC3339, r.URL.Query().Get("timeMax"))
+
+		events := []*event{}
+		for _, e := range srv.events {
+			id := e.Calendar
+			if id == "" {
+				id = "primary"
+			}
+			if id != calendarID && !(id == "primary" && calendarID == srv.me) {
+				continue
+			}
+			if minErr == nil && !e.end.After(timeMin) {
+				continue
+			}
+			if maxErr == nil && !e.start.Before(timeMax) {
+				continue
+			}
+			events = append(events, e)
+		}
+		slices.SortStableFunc(events, func(a, b *event) int {
+			return a.start.Compare(b.start)
+		})
+
+		items := make([]*calendar.Event, len(events))
+		for i, e := range events {
+			items[i] = srv.calendarEvent(e)
+		}
+		writeJSON(w, &calendar.Events{
+			Kind:     "calendar#events",
+			Summary:  calendarID,
+			TimeZone: srv.loc.String(),
+			Items:    items,
+		})
+	})
+}
+
+func (srv *Server) calendarEvent(e *event) *calendar.Event {
+	eventType := e.EventType
+	if eventType == "" {
+		eventType = "default"
+	}
+	item := &calendar.Event{
+		Kind:        "calendar#event",
+		Id:          e.id,
+		ICalUID:     e.id + "@fake",
+		Status:      "confirmed",
+		Summary:     e.Summary,
+		Description: e.Description,
+		EventType:   eventType,
+		Organizer: &calendar.EventOrganizer{
+			Email: e.organizer,
+			Self:  e.organizer == srv.me,
+		},
+	}
+	if e.AllDay {
+		item.Start = &calendar.EventDateTime{Date: e.start.Format(time.DateOnly)}
+		item.End = &calendar.EventDateTime{Date: e.end.Format(time.DateOnly)}
+	} else {
+		item.Start = &calendar.EventDateTime{DateTime: e.start.Format(time.RFC3339)}
+		item.End = &calendar.EventDateTime{DateTime: e.end.Format(time.RFC3339)}
+	}
+	for _, a := range e.Attendees {
+		email := srv.email(a.Email)
+		response := a.Response
+		if response == "" {
+			response = "accepted"
+		}
+		item.Attendees = append(item.Attendees, &calendar.EventAttendee{
+			Email:          email,
+			DisplayName:    srv.name(email),
+			ResponseStatus: response,
+			Resource:       a.Resource,
+			Self:           email == srv.me,
+			Organizer:      email == e.organizer,
+		})
+	}
+	return item
+}
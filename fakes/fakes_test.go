@@ -0,0 +1,218 @@
+package fakes
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/abibby/what-it-do/atlassian"
+	"github.com/abibby/what-it-do/bitbucket"
+	"github.com/andygrunwald/go-jira"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+func newTestServer(t *testing.T, s *Scenario) *http.Client {
+	t.Helper()
+	srv, err := NewServer(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+	return &http.Client{Transport: srv.Transport("test", nil)}
+}
+
+func TestExampleScenario(t *testing.T) {
+	s, err := LoadScenario("example.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := NewServer(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+}
+
+func TestJira(t *testing.T) {
+	client := newTestServer(t, &Scenario{
+		Day: "2024-11-12",
+		Me:  Person{Email: "me@example.com", Name: "Me"},
+		Issues: []*Issue{
+			{
+				Key:      "PD-1",
+				Summary:  "First",
+				Assignee: "me",
+				Changes: []*Change{
+					{At: "10:00", From: "In Progress", To: "In Review"},
+					{At: "-1d 09:00", From: "To Do", To: "In Progress"},
+				},
+			},
+			{Key: "PD-2", Summary: "Second", Status: "Done"},
+		},
+	})
+
+	resources, err := atlassian.NewClient(client).AccessibleResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 1 || resources[0].ID != CloudID {
+		t.Fatalf("unexpected resources %+v", resources)
+	}
+
+	jiraClient, err := jira.NewClient(client, "https://api.atlassian.com/ex/jira/"+CloudID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	self, _, err := jiraClient.User.GetSelf()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if self.DisplayName != "Me" || self.AccountID == "" {
+		t.Errorf("unexpected user %+v", self)
+	}
+
+	issues, _, err := jiraClient.Issue.Search("project = PD", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %d", len(issues))
+	}
+	if issues[0].Fields.Status.Name != "In Review" || issues[0].Fields.Assignee.AccountID != self.AccountID {
+		t.Errorf("unexpected status %q or assignee %+v", issues[0].Fields.Status.Name, issues[0].Fields.Assignee)
+	}
+	if issues[1].Fields.Status.Name != "Done" || issues[1].Fields.Assignee != nil {
+		t.Errorf("unexpected status %q or assignee %+v", issues[1].Fields.Status.Name, issues[1].Fields.Assignee)
+	}
+
+	req, err := jiraClient.NewRequest(http.MethodGet, "rest/api/3/issue/"+issues[0].ID+"/changelog", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changelog := &struct {
+		Values []*jira.ChangelogHistory `json:"values"`
+	}{}
+	_, err = jiraClient.Do(req, changelog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changelog.Values) != 2 || changelog.Values[0].Created != "2024-11-11T09:00:00.000"+offset(t, "2024-11-11") {
+		t.Errorf("changes should be sorted by time, got %+v", changelog.Values)
+	}
+
+	_, resp, err := jiraClient.Issue.Get("PD-3", nil)
+	if err == nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a 404 for a missing issue, got %v", err)
+	}
+}
+
+func TestBitbucket(t *testing.T) {
+	testCases := []struct {
+		name          string
+		noInternalAPI bool
+		want          []string
+	}{
+		// the internal endpoint filters on followers
+		{"internal api", false, []string{"today"}},
+		// the repository fallback returns every pull request updated that day
+		{"repository fallback", true, []string{"today", "not following"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newTestServer(t, &Scenario{
+				Day:           "2024-11-12",
+				Me:            Person{Email: "me@example.com"},
+				NoInternalAPI: tc.noInternalAPI,
+				PullRequests: []*PullRequest{
+					{Title: "today", Author: "sam@example.com", Participants: []*Participant{{User: "me", At: "10:00"}}},
+					{Title: "yesterday", Author: "sam@example.com", Participants: []*Participant{{User: "me", At: "-1d 10:00"}}},
+					{Title: "not following", Repository: "api", Author: "sam@example.com", Participants: []*Participant{{User: "alex@example.com", At: "10:00"}}},
+					{Title: "declined", State: "DECLINED", Participants: []*Participant{{User: "me", At: "10:00"}}},
+				},
+			})
+			bbClient := bitbucket.NewClient(client)
+			u, err := bbClient.CurrentUser()
+			if err != nil {
+				t.Fatal(err)
+			}
+			day, err := time.ParseInLocation(time.DateOnly, "2024-11-12", time.Local)
+			if err != nil {
+				t.Fatal(err)
+			}
+			prs, err := bbClient.ListFollowedPullRequests(&bitbucket.ListFollowedPullRequestsOptions{
+				Workspace: "example",
+				User:      u,
+				Start:     day,
+				End:       day.AddDate(0, 0, 1).Add(-1),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			titles := []string{}
+			for pr := range prs.All() {
+				titles = append(titles, pr.Title)
+			}
+			slices.Sort(titles)
+			want := slices.Sorted(slices.Values(tc.want))
+			if !slices.Equal(titles, want) {
+				t.Errorf("expected %v, got %v", want, titles)
+			}
+		})
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	client := newTestServer(t, &Scenario{
+		Day: "2024-11-12",
+		Me:  Person{Email: "me@example.com"},
+		Events: []*Event{
+			{Summary: "later", Start: "13:00", End: "14:00"},
+			{Summary: "standup", Start: "09:30", End: "09:45", Attendees: []*Attendee{{Email: "me"}, {Email: "alex@example.com", Response: "tentative"}}},
+			{Summary: "off", AllDay: true},
+			{Summary: "tomorrow", Start: "+1d 09:00", End: "+1d 10:00"},
+			{Summary: "other calendar", Calendar: "team", Start: "09:00", End: "10:00"},
+		},
+	})
+	srv, err := calendar.NewService(context.Background(), option.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	day, err := time.ParseInLocation(time.DateOnly, "2024-11-12", time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := srv.Events.List("primary").
+		TimeMin(day.Format(time.RFC3339)).
+		TimeMax(day.AddDate(0, 0, 1).Add(-1).Format(time.RFC3339)).
+		Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	summaries := []string{}
+	for _, e := range events.Items {
+		summaries = append(summaries, e.Summary)
+	}
+	want := []string{"off", "standup", "later"}
+	if len(summaries) != len(want) || summaries[0] != want[0] || summaries[1] != want[1] || summaries[2] != want[2] {
+		t.Fatalf("expected %v, got %v", want, summaries)
+	}
+	standup := events.Items[1]
+	if !standup.Attendees[0].Self || standup.Attendees[1].ResponseStatus != "tentative" || !standup.Organizer.Self {
+		t.Errorf("unexpected attendees %+v", standup.Attendees)
+	}
+	if events.Items[0].Start.Date != "2024-11-12" {
+		t.Errorf("expected an all day event, got %+v", events.Items[0].Start)
+	}
+}
+
+// offset returns the local zone's offset on day in jira's format.
+func offset(t *testing.T, day string) string {
+	d, err := time.ParseInLocation(time.DateOnly, day, time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d.Format("-0700")
+}
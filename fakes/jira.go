@@ -0,0 +1,116 @@
+package fakes
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+// CloudID is the id of the fake Jira site.
+const CloudID = "00000000-fake-4000-8000-000000000000"
+
+const jiraTimeFormat = "2006-01-02T15:04:05.000-0700"
+
+func (srv *Server) handleAtlassian(mux *http.ServeMux) {
+	mux.HandleFunc("GET /oauth/token/accessible-resources", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []map[string]any{{
+			"id":     CloudID,
+			"name":   "fake",
+			"url":    "https://fake.atlassian.net",
+			"scopes": []string{"read:jira-work", "read:jira-user"},
+		}})
+	})
+
+	prefix := "/ex/jira/" + CloudID
+	mux.HandleFunc("GET "+prefix+"/rest/api/2/myself", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, srv.jiraUser(srv.me))
+	})
+	// the jql is ignored, every issue in the scenario is returned
+	mux.HandleFunc("GET "+prefix+"/rest/api/2/search", func(w http.ResponseWriter, r *http.Request) {
+		issues := []any{}
+		for _, is := range srv.issues {
+			issues = append(issues, srv.jiraIssue(is))
+		}
+		writeJSON(w, map[string]any{
+			"startAt":    0,
+			"maxResults": len(issues),
+			"total":      len(issues),
+			"issues":     issues,
+		})
+	})
+	mux.HandleFunc("GET "+prefix+"/rest/api/2/issue/{key}", func(w http.ResponseWriter, r *http.Request) {
+		is := srv.findIssue(r.PathValue("key"))
+		if is == nil {
+			writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.")
+			return
+		}
+		writeJSON(w, srv.jiraIssue(is))
+	})
+	mux.HandleFunc("GET "+prefix+"/rest/api/3/issue/{key}/changelog", func(w http.ResponseWriter, r *http.Request) {
+		is := srv.findIssue(r.PathValue("key"))
+		if is == nil {
+			writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.")
+			return
+		}
+		values := []*jira.ChangelogHistory{}
+		for i, c := range is.changes {
+			values = append(values, &jira.ChangelogHistory{
+				Id:      fmt.Sprintf("%s%02d", is.id, i+1),
+				Author:  *srv.jiraUser(c.author),
+				Created: c.at.Format(jiraTimeFormat),
+				Items: []jira.ChangelogItems{{
+					Field:      c.field,
+					FieldType:  "jira",
+					FromString: c.From,
+					ToString:   c.To,
+				}},
+			})
+		}
+		writeJSON(w, map[string]any{
+			"startAt":    0,
+			"maxResults": len(values),
+			"total":      len(values),
+			"isLast":     true,
+			"values":     values,
+		})
+	})
+}
+
+func (srv *Server) findIssue(key string) *issue {
+	for _, is := range srv.issues {
+		if is.id == key || is.Key == key {
+			return is
+		}
+	}
+	return nil
+}
+
+func (srv *Server) jiraUser(email string) *jira.User {
+	return &jira.User{
+		AccountID:    id(email),
+		EmailAddress: email,
+		DisplayName:  srv.name(email),
+		Active:       true,
+	}
+}
+
+func (srv *Server) jiraIssue(is *issue) map[string]any {
+	typ := is.Type
+	if typ == "" {
+		typ = "Story"
+	}
+	fields := map[string]any{
+		"summary":   is.Summary,
+		"issuetype": map[string]any{"name": typ},
+		"status":    map[string]any{"name": is.status},
+	}
+	if is.assignee != "" {
+		fields["assignee"] = srv.jiraUser(is.assignee)
+	}
+	return map[string]any{
+		"id":     is.id,
+		"key":    is.Key,
+		"fields": fields,
+	}
+}
@@ -0,0 +1,161 @@
+package fakes
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is the data served by the fake servers. People are identified by
+// their email, the signed in user is Me.
+type Scenario struct {
+	// Day is the day clock times are on, as 2006-01-02. Defaults to today.
+	Day string `yaml:"day"`
+	// TimeZone is the IANA zone clock times are in, defaults to the local
+	// zone.
+	TimeZone string `yaml:"time_zone"`
+	Me       Person `yaml:"me"`
+	// People are the names of the other people in the scenario, people that
+	// aren't listed are named after their email.
+	People []Person `yaml:"people"`
+
+	Issues       []*Issue       `yaml:"issues"`
+	PullRequests []*PullRequest `yaml:"pull_requests"`
+	Events       []*Event       `yaml:"events"`
+
+	// NoInternalAPI answers Bitbucket's internal workspace pull request
+	// endpoint with a 404, so the pull requests are fetched from each
+	// repository instead.
+	NoInternalAPI bool `yaml:"no_internal_api"`
+}
+
+type Person struct {
+	Email string `yaml:"email"`
+	Name  string `yaml:"name"`
+}
+
+// Issue is a jira issue.
+type Issue struct {
+	Key     string `yaml:"key"`
+	Summary string `yaml:"summary"`
+	// Type defaults to Story.
+	Type string `yaml:"type"`
+	// Status defaults to the last status change or To Do.
+	Status string `yaml:"status"`
+	// Assignee is an email, "me" for the signed in user or empty for
+	// unassigned issues.
+	Assignee string    `yaml:"assignee"`
+	Changes  []*Change `yaml:"changes"`
+}
+
+// Change is an entry in an issue's changelog.
+type Change struct {
+	At Time `yaml:"at"`
+	// Author defaults to me.
+	Author string `yaml:"author"`
+	// Field defaults to status.
+	Field string `yaml:"field"`
+	From  string `yaml:"from"`
+	To    string `yaml:"to"`
+}
+
+// PullRequest is a Bitbucket pull request in the workspace.
+type PullRequest struct {
+	// ID defaults to the pull request's position in the scenario.
+	ID    int    `yaml:"id"`
+	Title string `yaml:"title"`
+	// State defaults to OPEN.
+	State string `yaml:"state"`
+	// Repository is the slug of the pull request's repository, defaults to
+	// app.
+	Repository string `yaml:"repository"`
+	// Author defaults to me.
+	Author       string         `yaml:"author"`
+	Participants []*Participant `yaml:"participants"`
+}
+
+type Participant struct {
+	User string `yaml:"user"`
+	// Role defaults to REVIEWER.
+	Role     string `yaml:"role"`
+	Approved bool   `yaml:"approved"`
+	At       Time   `yaml:"at"`
+}
+
+// Event is a Google Calendar event.
+type Event struct {
+	// Calendar is the calendar id, defaults to primary.
+	Calendar    string `yaml:"calendar"`
+	Summary     string `yaml:"summary"`
+	Description string `yaml:"description"`
+	// AllDay events cover the day of Start, which defaults to the scenario's
+	// day.
+	AllDay bool `yaml:"all_day"`
+	Start  Time `yaml:"start"`
+	End    Time `yaml:"end"`
+	// EventType defaults to default, Google also uses outOfOffice and
+	// focusTime.
+	EventType string `yaml:"event_type"`
+	// Organizer defaults to me.
+	Organizer string      `yaml:"organizer"`
+	Attendees []*Attendee `yaml:"attendees"`
+}
+
+type Attendee struct {
+	Email string `yaml:"email"`
+	// Response defaults to accepted.
+	Response string `yaml:"response"`
+	Resource bool   `yaml:"resource"`
+}
+
+// LoadScenario reads a yaml scenario.
+func LoadScenario(file string) (*Scenario, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	s := &Scenario{}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	err = dec.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %w", file, err)
+	}
+	return s, nil
+}
+
+// Time is an RFC 3339 time or a clock time like 09:30 on the scenario's day.
+// Clock times can be moved by whole days, e.g. "-1d 16:00" is the afternoon
+// before.
+type Time string
+
+// At returns the Time for t.
+func At(t time.Time) Time {
+	return Time(t.Format(time.RFC3339))
+}
+
+var clockRE = regexp.MustCompile(`^(?:([+-]\d+)d\s+)?(\d{1,2}:\d{2})$`)
+
+func (t Time) resolve(day time.Time) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, string(t)); err == nil {
+		return parsed, nil
+	}
+	m := clockRE.FindStringSubmatch(string(t))
+	if m == nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, must be RFC 3339 or a clock time like 09:30", t)
+	}
+	days := 0
+	if m[1] != "" {
+		days, _ = strconv.Atoi(m[1])
+	}
+	clock, err := time.Parse("15:04", m[2])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: %w", t, err)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day()+days, clock.Hour(), clock.Minute(), 0, 0, day.Location()), nil
+}
@@ -0,0 +1,280 @@
+// Package fakes runs in process servers that emulate the Atlassian, Jira,
+// Bitbucket and Google Calendar endpoints used by the sources, so reports can
+// be worked on without touching real accounts. Clients are pointed at them
+// with Server.Transport through ezoauth.WrapTransport.
+package fakes
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Server serves a scenario.
+type Server struct {
+	*httptest.Server
+
+	me            string
+	loc           *time.Location
+	noInternalAPI bool
+	names         map[string]string
+	issues        []*issue
+	prs           []*pullRequest
+	events        []*event
+}
+
+type issue struct {
+	*Issue
+	id       string
+	assignee string
+	status   string
+	changes  []*change
+}
+
+type change struct {
+	*Change
+	at     time.Time
+	author string
+	field  string
+}
+
+type pullRequest struct {
+	*PullRequest
+	id           int
+	repository   string
+	author       string
+	participants []*participant
+	updated      time.Time
+}
+
+type participant struct {
+	*Participant
+	user string
+	at   time.Time
+}
+
+type event struct {
+	*Event
+	id        string
+	organizer string
+	start     time.Time
+	end       time.Time
+}
+
+// NewServer starts a server for the scenario, it must be closed when it is no
+// longer used.
+func NewServer(s *Scenario) (*Server, error) {
+	srv := &Server{
+		me:            s.Me.Email,
+		names:         map[string]string{},
+		noInternalAPI: s.NoInternalAPI,
+	}
+	if srv.me == "" {
+		srv.me = "me@example.com"
+	}
+	for _, p := range append([]Person{s.Me}, s.People...) {
+		if p.Name != "" {
+			srv.names[p.Email] = p.Name
+		}
+	}
+
+	loc := time.Local
+	if s.TimeZone != "" {
+		var err error
+		loc, err = time.LoadLocation(s.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone: %w", err)
+		}
+	}
+	day := startOfDay(time.Now().In(loc))
+	if s.Day != "" {
+		var err error
+		day, err = time.ParseInLocation(time.DateOnly, s.Day, loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid day: %w", err)
+		}
+	}
+	srv.loc = loc
+
+	err := srv.load(s, day)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	srv.handleAtlassian(mux)
+	srv.handleBitbucket(mux)
+	srv.handleCalendar(mux)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("fakes: no handler for %s %s", r.Method, r.URL.Path))
+	})
+	srv.Server = httptest.NewServer(mux)
+	return srv, nil
+}
+
+func (srv *Server) load(s *Scenario, day time.Time) error {
+	for i, is := range s.Issues {
+		fi := &issue{
+			Issue:    is,
+			id:       fmt.Sprint(10000 + i + 1),
+			assignee: srv.email(is.Assignee),
+			status:   is.Status,
+		}
+		for _, c := range is.Changes {
+			at, err := c.At.resolve(day)
+			if err != nil {
+				return fmt.Errorf("issue %s: %w", is.Key, err)
+			}
+			fc := &change{Change: c, at: at, author: srv.email(c.Author), field: c.Field}
+			if fc.author == "" {
+				fc.author = srv.me
+			}
+			if fc.field == "" {
+				fc.field = "status"
+			}
+			fi.changes = append(fi.changes, fc)
+		}
+		slices.SortStableFunc(fi.changes, func(a, b *change) int {
+			return a.at.Compare(b.at)
+		})
+		if fi.status == "" {
+			fi.status = "To Do"
+			for _, c := range fi.changes {
+				if c.field == "status" {
+					fi.status = c.To
+				}
+			}
+		}
+		srv.issues = append(srv.issues, fi)
+	}
+
+	for i, pr := range s.PullRequests {
+		fp := &pullRequest{PullRequest: pr, id: pr.ID, repository: pr.Repository, author: srv.email(pr.Author)}
+		if fp.id == 0 {
+			fp.id = i + 1
+		}
+		if fp.repository == "" {
+			fp.repository = "app"
+		}
+		if fp.author == "" {
+			fp.author = srv.me
+		}
+		for _, p := range pr.Participants {
+			at, err := p.At.resolve(day)
+			if err != nil {
+				return fmt.Errorf("pull request %d: %w", fp.id, err)
+			}
+			fp.participants = append(fp.participants, &participant{Participant: p, user: srv.email(p.User), at: at})
+			if at.After(fp.updated) {
+				fp.updated = at
+			}
+		}
+		srv.prs = append(srv.prs, fp)
+	}
+
+	for i, e := range s.Events {
+		fe := &event{Event: e, id: fmt.Sprintf("fake%d", i+1), organizer: srv.email(e.Organizer)}
+		if fe.organizer == "" {
+			fe.organizer = srv.me
+		}
+		var err error
+		if e.AllDay {
+			fe.start = day
+			if e.Start != "" {
+				fe.start, err = e.Start.resolve(day)
+				if err != nil {
+					return fmt.Errorf("event %q: %w", e.Summary, err)
+				}
+			}
+			fe.start = startOfDay(fe.start)
+			fe.end = fe.start.AddDate(0, 0, 1)
+		} else {
+			fe.start, err = e.Start.resolve(day)
+			if err != nil {
+				return fmt.Errorf("event %q: %w", e.Summary, err)
+			}
+			fe.end, err = e.End.resolve(day)
+			if err != nil {
+				return fmt.Errorf("event %q: %w", e.Summary, err)
+			}
+		}
+		srv.events = append(srv.events, fe)
+	}
+	return nil
+}
+
+// email resolves "me" to the signed in user's email.
+func (srv *Server) email(email string) string {
+	if strings.EqualFold(email, "me") {
+		return srv.me
+	}
+	return email
+}
+
+// name returns the display name of the person with the email.
+func (srv *Server) name(email string) string {
+	if name, ok := srv.names[email]; ok {
+		return name
+	}
+	name, _, _ := strings.Cut(email, "@")
+	return name
+}
+
+// Transport returns a transport that sends requests to the fake server
+// instead of the real hosts, rt is never used. It matches the signature of
+// ezoauth.WrapTransport.
+func (srv *Server) Transport(service string, rt http.RoundTripper) http.RoundTripper {
+	return &transport{server: srv}
+}
+
+type transport struct {
+	server *Server
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	u, err := url.Parse(t.server.URL)
+	if err != nil {
+		return nil, err
+	}
+	slog.Debug("Fake request", "url", req.URL)
+	r := req.Clone(req.Context())
+	r.URL.Scheme = u.Scheme
+	r.URL.Host = u.Host
+	r.Host = ""
+	return t.server.Client().Transport.RoundTrip(r)
+}
+
+// id returns a stable id for an email.
+func id(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(email)))
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		slog.Warn("Failed to write fake response", "err", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"code":    status,
+		"message": message,
+	})
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
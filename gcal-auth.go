@@ -13,24 +13,35 @@ import (
 	"google.golang.org/api/option"
 )
 
-func getGCalService() (*calendar.Service, error) {
-	ctx := context.Background()
-	creds, err := os.ReadFile(config.Dir("google_creds.json"))
+func googleOAuthConfig() (*ezoauth.Config, error) {
+	creds, err := os.ReadFile(config.Path("google_creds.json"))
 	if err != nil {
 		return nil, fmt.Errorf("Unable to read client secret file: %v", err)
 	}
 
 	// If modifying these scopes, delete your previously saved token.json.
-	config, err := google.ConfigFromJSON(creds, calendar.CalendarReadonlyScope)
+	oauthConfig, err := google.ConfigFromJSON(creds, calendar.CalendarReadonlyScope)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse client secret file to config: %w", err)
 	}
-	ezconfig := &ezoauth.Config{
+	// client secrets don't include the device endpoint
+	oauthConfig.Endpoint.DeviceAuthURL = google.Endpoint.DeviceAuthURL
+	return &ezoauth.Config{
 		Name:        "google",
-		OAuthConfig: config,
+		OAuthConfig: oauthConfig,
 		AuthCodeURLOpts: []oauth2.AuthCodeOption{
 			oauth2.AccessTypeOffline,
 		},
+		// desktop app clients accept any port on http://localhost
+		EphemeralPort: true,
+	}, nil
+}
+
+func getGCalService() (*calendar.Service, error) {
+	ctx := context.Background()
+	ezconfig, err := googleOAuthConfig()
+	if err != nil {
+		return nil, err
 	}
 	client, err := ezconfig.Client(ctx)
 	if err != nil {
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/andygrunwald/go-jira"
+	"google.golang.org/api/calendar/v3"
+)
+
+// eventJiraID returns the first jira key found in the event's summary,
+// description or attachments.
+func eventJiraID(item *calendar.Event) string {
+	texts := []string{item.Summary, item.Description}
+	for _, attachment := range item.Attachments {
+		texts = append(texts, attachment.Title, attachment.FileUrl)
+	}
+	for _, text := range texts {
+		if id := jiraRE.FindString(text); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// verifyJiraIDs looks up the jira issue linked to each row. Rows linked to
+// issues that don't exist are unlinked and the rest have their description
+// replaced with the issue summary.
+func verifyJiraIDs(rows []*Row) error {
+	jiraClient, err := getJiraClient()
+	if err != nil {
+		return err
+	}
+
+	summaries := map[string]string{}
+	for _, row := range rows {
+		if row.JiraID == "" {
+			continue
+		}
+		summary, ok := summaries[row.JiraID]
+		if !ok {
+			issue, resp, err := jiraClient.Issue.Get(row.JiraID, &jira.GetQueryOptions{Fields: "summary"})
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				slog.Info("Linked jira issue not found", "key", row.JiraID)
+			} else if err != nil {
+				return fmt.Errorf("get issue %s: %w", row.JiraID, err)
+			} else if issue.Fields == nil {
+				return fmt.Errorf("get issue %s: no fields returned", row.JiraID)
+			} else {
+				summary = issue.Fields.Summary
+			}
+			summaries[row.JiraID] = summary
+		}
+
+		if summary == "" {
+			row.JiraID = ""
+			continue
+		}
+		row.Description = summary
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// eventRule categorises timed events. Every condition that is set must match.
+type eventRule struct {
+	Project string `json:"project"`
+	// HideSummary leaves the description empty instead of using the event
+	// summary.
+	HideSummary bool `json:"hide_summary"`
+
+	// Summary is a case insensitive substring of the event summary.
+	Summary string `json:"summary"`
+	// AttendeeDomains matches events with an attendee from one of the
+	// domains.
+	AttendeeDomains []string `json:"attendee_domains"`
+	// External matches events with an attendee outside of internal_domains.
+	// It never matches when the internal domains aren't known.
+	External bool `json:"external"`
+	// MinAttendees and MaxAttendees bound the number of attendees including
+	// you, rooms and other resources aren't counted.
+	MinAttendees int `json:"min_attendees"`
+	MaxAttendees int `json:"max_attendees"`
+	// Organizers matches events organized by one of the email addresses.
+	Organizers []string `json:"organizers"`
+	// VideoLink matches events with (true) or without (false) a Meet, Zoom
+	// or Teams link.
+	VideoLink *bool `json:"video_link"`
+
+	// matchCase makes Summary case sensitive, the built in rules have
+	// always been.
+	matchCase bool
+}
+
+var defaultEventRules = []*eventRule{
+	{Summary: "Standup", Project: "Meetings - Daily Standup", HideSummary: true, matchCase: true},
+	{Summary: "Sprint Demo", Project: "Meetings - Sprint Demo", HideSummary: true, matchCase: true},
+	{Summary: "Backlog Refinement", Project: "Meetings - Backlog Refinement", HideSummary: true, matchCase: true},
+}
+
+func (r *eventRule) matches(item *calendar.Event, internalDomains []string) bool {
+	if r.Summary != "" && !r.matchesSummary(item.Summary) {
+		return false
+	}
+
+	attendees := people(item)
+	if r.MinAttendees != 0 && len(attendees) < r.MinAttendees {
+		return false
+	}
+	if r.MaxAttendees != 0 && len(attendees) > r.MaxAttendees {
+		return false
+	}
+	if len(r.AttendeeDomains) > 0 && !slices.ContainsFunc(attendees, func(a *calendar.EventAttendee) bool {
+		return a.Email != "" && containsFold(r.AttendeeDomains, emailDomain(a.Email))
+	}) {
+		return false
+	}
+	if r.External && (len(internalDomains) == 0 || !slices.ContainsFunc(attendees, func(a *calendar.EventAttendee) bool {
+		// you are never external, even on events without your address, and
+		// attendees without an address can't be placed
+		return !a.Self && a.Email != "" && !containsFold(internalDomains, emailDomain(a.Email))
+	})) {
+		return false
+	}
+	if len(r.Organizers) > 0 && (item.Organizer == nil || !containsFold(r.Organizers, item.Organizer.Email)) {
+		return false
+	}
+	if r.VideoLink != nil && *r.VideoLink != hasVideoLink(item) {
+		return false
+	}
+	return true
+}
+
+func (r *eventRule) matchesSummary(summary string) bool {
+	if r.matchCase {
+		return strings.Contains(summary, r.Summary)
+	}
+	return strings.Contains(strings.ToLower(summary), strings.ToLower(r.Summary))
+}
+
+// people returns the attendees that aren't rooms or other resources. Events
+// without an attendee list only have you.
+func people(item *calendar.Event) []*calendar.EventAttendee {
+	if len(item.Attendees) == 0 {
+		return []*calendar.EventAttendee{{Self: true}}
+	}
+	result := []*calendar.EventAttendee{}
+	for _, a := range item.Attendees {
+		if !a.Resource {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// internalDomains returns the configured internal domains, defaulting to the
+// domain of your own address on the event.
+func internalDomains(cfg *calendarConfig, item *calendar.Event) []string {
+	if len(cfg.InternalDomains) > 0 {
+		return cfg.InternalDomains
+	}
+	for _, a := range item.Attendees {
+		if a.Self && a.Email != "" {
+			return []string{emailDomain(a.Email)}
+		}
+	}
+	if item.Organizer != nil && item.Organizer.Self && item.Organizer.Email != "" {
+		return []string{emailDomain(item.Organizer.Email)}
+	}
+	return nil
+}
+
+var videoLinkHosts = []string{
+	"meet.google.com/",
+	"zoom.us/j/",
+	"zoom.us/my/",
+	"teams.microsoft.com/l/meetup-join",
+}
+
+func hasVideoLink(item *calendar.Event) bool {
+	if item.HangoutLink != "" {
+		return true
+	}
+	if item.ConferenceData != nil {
+		for _, ep := range item.ConferenceData.EntryPoints {
+			if ep.EntryPointType == "video" {
+				return true
+			}
+		}
+	}
+	for _, text := range []string{item.Location, item.Description} {
+		for _, host := range videoLinkHosts {
+			if strings.Contains(text, host) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func emailDomain(email string) string {
+	_, domain, _ := strings.Cut(email, "@")
+	return strings.ToLower(domain)
+}
+
+func containsFold(haystack []string, needle string) bool {
+	return slices.ContainsFunc(haystack, func(s string) bool {
+		return strings.EqualFold(s, needle)
+	})
+}
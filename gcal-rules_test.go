@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestEventRuleMatches(t *testing.T) {
+	me := &calendar.EventAttendee{Email: "me@example.com", Self: true}
+	coworker := &calendar.EventAttendee{Email: "alex@example.com"}
+	client := &calendar.EventAttendee{Email: "pat@acme.test"}
+	room := &calendar.EventAttendee{Email: "room-4@resource.example.com", Resource: true}
+	noEmail := &calendar.EventAttendee{DisplayName: "Dial in"}
+	internal := []string{"example.com"}
+	yes, no := true, false
+
+	testCases := []struct {
+		name     string
+		rule     *eventRule
+		event    *calendar.Event
+		internal []string
+		want     bool
+	}{
+		{"summary", &eventRule{Summary: "sync"}, &calendar.Event{Summary: "Acme Weekly Sync"}, internal, true},
+		{"summary miss", &eventRule{Summary: "sync"}, &calendar.Event{Summary: "Planning"}, internal, false},
+		{"built in summary is case sensitive", defaultEventRules[0], &calendar.Event{Summary: "standup"}, internal, false},
+		{"built in summary", defaultEventRules[0], &calendar.Event{Summary: "Team Standup"}, internal, true},
+
+		{"external", &eventRule{External: true}, &calendar.Event{Attendees: []*calendar.EventAttendee{me, coworker, client}}, internal, true},
+		{"external domain is case insensitive", &eventRule{External: true}, &calendar.Event{Attendees: []*calendar.EventAttendee{me, {Email: "Alex@Example.COM"}}}, internal, false},
+		{"internal only", &eventRule{External: true}, &calendar.Event{Attendees: []*calendar.EventAttendee{me, coworker}}, internal, false},
+		{"no attendees", &eventRule{External: true}, &calendar.Event{Summary: "Focus block"}, internal, false},
+		{"self on another domain", &eventRule{External: true}, &calendar.Event{Attendees: []*calendar.EventAttendee{{Email: "me@personal.test", Self: true}, coworker}}, internal, false},
+		{"attendee without email", &eventRule{External: true}, &calendar.Event{Attendees: []*calendar.EventAttendee{me, noEmail}}, internal, false},
+		{"external room", &eventRule{External: true}, &calendar.Event{Attendees: []*calendar.EventAttendee{me, {Email: "room@acme.test", Resource: true}}}, internal, false},
+		{"unknown internal domains", &eventRule{External: true}, &calendar.Event{Attendees: []*calendar.EventAttendee{coworker, client}}, nil, false},
+
+		{"attendee domain", &eventRule{AttendeeDomains: []string{"ACME.test"}}, &calendar.Event{Attendees: []*calendar.EventAttendee{me, client}}, internal, true},
+		{"attendee domain miss", &eventRule{AttendeeDomains: []string{"acme.test"}}, &calendar.Event{Attendees: []*calendar.EventAttendee{me, coworker}}, internal, false},
+		{"empty attendee domain", &eventRule{AttendeeDomains: []string{""}}, &calendar.Event{Attendees: []*calendar.EventAttendee{me, noEmail}}, internal, false},
+		{"attendee domain without attendees", &eventRule{AttendeeDomains: []string{""}}, &calendar.Event{}, internal, false},
+
+		{"one on one", &eventRule{MinAttendees: 2, MaxAttendees: 2}, &calendar.Event{Attendees: []*calendar.EventAttendee{me, coworker, room}}, internal, true},
+		{"too many", &eventRule{MaxAttendees: 2}, &calendar.Event{Attendees: []*calendar.EventAttendee{me, coworker, client}}, internal, false},
+		{"too few", &eventRule{MinAttendees: 2}, &calendar.Event{}, internal, false},
+
+		{"organizer", &eventRule{Organizers: []string{"Hiring@example.com"}}, &calendar.Event{Organizer: &calendar.EventOrganizer{Email: "hiring@example.com"}}, internal, true},
+		{"organizer miss", &eventRule{Organizers: []string{"hiring@example.com"}}, &calendar.Event{Organizer: &calendar.EventOrganizer{Email: "alex@example.com"}}, internal, false},
+		{"no organizer", &eventRule{Organizers: []string{"hiring@example.com"}}, &calendar.Event{}, internal, false},
+
+		{"video link", &eventRule{VideoLink: &yes}, &calendar.Event{Location: "https://acme.zoom.us/j/123"}, internal, true},
+		{"hangout link", &eventRule{VideoLink: &yes}, &calendar.Event{HangoutLink: "https://meet.google.com/abc-defg-hij"}, internal, true},
+		{"no video link", &eventRule{VideoLink: &no}, &calendar.Event{Location: "Room 4"}, internal, true},
+		{"unexpected video link", &eventRule{VideoLink: &no}, &calendar.Event{Description: "Join at https://meet.google.com/abc-defg-hij"}, internal, false},
+
+		{"every condition", &eventRule{Summary: "sync", External: true, MaxAttendees: 3}, &calendar.Event{Summary: "Acme sync", Attendees: []*calendar.EventAttendee{me, client}}, internal, true},
+		{"one condition fails", &eventRule{Summary: "sync", External: true, MaxAttendees: 1}, &calendar.Event{Summary: "Acme sync", Attendees: []*calendar.EventAttendee{me, client}}, internal, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.rule.matches(tc.event, tc.internal); got != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestInternalDomains(t *testing.T) {
+	testCases := []struct {
+		name  string
+		cfg   *calendarConfig
+		event *calendar.Event
+		want  []string
+	}{
+		{"configured", &calendarConfig{InternalDomains: []string{"example.com"}}, &calendar.Event{}, []string{"example.com"}},
+		{"self attendee", &calendarConfig{}, &calendar.Event{Attendees: []*calendar.EventAttendee{{Email: "pat@acme.test"}, {Email: "me@Example.com", Self: true}}}, []string{"example.com"}},
+		{"self organizer", &calendarConfig{}, &calendar.Event{Organizer: &calendar.EventOrganizer{Email: "me@example.com", Self: true}}, []string{"example.com"}},
+		{"self without email", &calendarConfig{}, &calendar.Event{Attendees: []*calendar.EventAttendee{{Self: true}}}, nil},
+		{"unknown", &calendarConfig{}, &calendar.Event{Attendees: []*calendar.EventAttendee{{Email: "pat@acme.test"}}}, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := internalDomains(tc.cfg, tc.event)
+			if len(got) != len(tc.want) || (len(got) > 0 && got[0] != tc.want[0]) {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
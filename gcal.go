@@ -1,63 +1,465 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"strings"
 	"time"
+
+	"github.com/abibby/what-it-do/config"
+	"github.com/abibby/what-it-do/overlap"
+	"google.golang.org/api/calendar/v3"
+)
+
+// calendarConfig is the optional calendar section of the config.
+type calendarConfig struct {
+	// Calendars are the Google calendars to read events from, defaults to
+	// the primary calendar. Set it to an empty list to only use ICS and
+	// CalDAV calendars.
+	Calendars []*calendarSource `json:"calendars"`
+	// ICS are .ics files or http(s) and webcal URLs to read events from.
+	ICS []*icsSource `json:"ics"`
+	// CalDAV are CalDAV calendar collections to read events from.
+	CalDAV []*calDAVSource `json:"caldav"`
+	// Outlook are Microsoft 365 calendars to read events from.
+	Outlook []*outlookSource `json:"outlook"`
+	// Emails are your addresses, they are used to find your RSVP in ICS and
+	// CalDAV events.
+	Emails []string `json:"emails"`
+	// IncludeResponses are the RSVP statuses of events that are counted,
+	// defaults to accepted, tentative and needsAction. Events without
+	// attendees are always counted.
+	IncludeResponses []string `json:"include_responses"`
+	// OverlapPolicy decides how time is credited when events overlap, one
+	// of first-wins, split or prefer-organizer. Defaults to split.
+	OverlapPolicy string `json:"overlap_policy"`
+	// AllDayRules map all day and out of office events to time off rows, the
+	// first matching rule is used and events that don't match any rule are
+	// skipped.
+	AllDayRules []*allDayRule `json:"all_day_rules"`
+	// WorkdayHours is the length of time off rows, defaults to 8h.
+	WorkdayHours config.Duration `json:"workday_hours"`
+	// TimeOffActivity is what to do with activity found by the other sources
+	// on a day off, either suppress or warn. Defaults to suppress.
+	TimeOffActivity string `json:"time_off_activity"`
+	// VerifyJira looks up jira keys found in events, dropping keys for issues
+	// that don't exist and using the issue summary as the description.
+	VerifyJira bool `json:"verify_jira"`
+	// Rules categorise timed events, the first matching rule is used. Events
+	// that don't match fall back to the solo project, the built in standup,
+	// demo and refinement rules and then the calendar's project.
+	Rules []*eventRule `json:"rules"`
+	// InternalDomains are the email domains of your organisation used by
+	// rules with external set. Defaults to the domain of your address on
+	// each event.
+	InternalDomains []string `json:"internal_domains"`
+	// SoloProject is the project used for events without any other
+	// attendees, like focus blocks. Defaults to "Focus Time - ".
+	SoloProject string `json:"solo_project"`
+}
+
+type calendarSource struct {
+	ID string `json:"id"`
+	// Project is used for events that don't match a more specific category,
+	// defaults to "Meetings - ".
+	Project string `json:"project"`
+}
+
+type allDayRule struct {
+	// Match is a case insensitive substring of the event summary.
+	Match string `json:"match"`
+	// EventType matches Google's event type e.g. outOfOffice.
+	EventType string `json:"event_type"`
+	Project   string `json:"project"`
+}
+
+func (r *allDayRule) matches(item *calendar.Event) bool {
+	if r.EventType != "" && r.EventType != item.EventType {
+		return false
+	}
+	if r.Match != "" && !strings.Contains(strings.ToLower(item.Summary), strings.ToLower(r.Match)) {
+		return false
+	}
+	return r.EventType != "" || r.Match != ""
+}
+
+var defaultAllDayRules = []*allDayRule{
+	{EventType: "outOfOffice", Project: "Time Off - Out of Office"},
+	{Match: "vacation", Project: "Time Off - Vacation"},
+	{Match: "holiday", Project: "Time Off - Holiday"},
+	{Match: "sick", Project: "Time Off - Sick"},
+}
+
+const (
+	timeOffSuppress = "suppress"
+	timeOffWarn     = "warn"
 )
 
+func readCalendarConfig() (*calendarConfig, error) {
+	cfg := &calendarConfig{}
+	err := config.Section("calendar", cfg)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if cfg.IncludeResponses == nil {
+		cfg.IncludeResponses = []string{"accepted", "tentative", "needsAction"}
+	}
+	if cfg.SoloProject == "" {
+		cfg.SoloProject = "Focus Time - "
+	}
+	if cfg.AllDayRules == nil {
+		cfg.AllDayRules = defaultAllDayRules
+	}
+	if cfg.TimeOffActivity == "" {
+		cfg.TimeOffActivity = timeOffSuppress
+	}
+	if cfg.TimeOffActivity != timeOffSuppress && cfg.TimeOffActivity != timeOffWarn {
+		return nil, fmt.Errorf("calendar: time_off_activity must be %s or %s", timeOffSuppress, timeOffWarn)
+	}
+	if cfg.OverlapPolicy == "" {
+		cfg.OverlapPolicy = string(overlap.Split)
+	}
+	_, err = overlap.ParsePolicy(cfg.OverlapPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("calendar: %w", err)
+	}
+	if cfg.Calendars == nil {
+		cfg.Calendars = []*calendarSource{{ID: "primary"}}
+	}
+	for i, c := range cfg.Calendars {
+		if c.ID == "" {
+			return nil, fmt.Errorf("calendar: calendars[%d] is missing an id", i)
+		}
+		if c.Project == "" {
+			c.Project = "Meetings - "
+		}
+	}
+	for i, c := range cfg.ICS {
+		if c.URL == "" {
+			return nil, fmt.Errorf("calendar: ics[%d] is missing a url", i)
+		}
+		if c.Project == "" {
+			c.Project = "Meetings - "
+		}
+	}
+	for i, c := range cfg.CalDAV {
+		if c.URL == "" {
+			return nil, fmt.Errorf("calendar: caldav[%d] is missing a url", i)
+		}
+		if c.Project == "" {
+			c.Project = "Meetings - "
+		}
+	}
+	for i, r := range cfg.Rules {
+		if r.Project == "" {
+			return nil, fmt.Errorf("calendar: rules[%d] is missing a project", i)
+		}
+	}
+	for _, c := range cfg.Outlook {
+		if c.Project == "" {
+			c.Project = "Meetings - "
+		}
+	}
+	return cfg, nil
+}
+
 func addCalenderEvents(start, end time.Time) ([]*Row, error) {
-	calendarService, err := getGCalService()
+	cfg, err := readCalendarConfig()
 	if err != nil {
 		return nil, err
 	}
-	events, err := calendarService.Events.List("primary").
+	feeds := []*calendarFeed{}
+	if len(cfg.Calendars) > 0 {
+		calendarService, err := getGCalService()
+		if err != nil {
+			return nil, err
+		}
+		for _, source := range cfg.Calendars {
+			feeds = append(feeds, &calendarFeed{
+				source: source,
+				fetch: func() ([]*calendar.Event, error) {
+					return listCalendarEvents(calendarService, source.ID, start, end)
+				},
+			})
+		}
+	}
+	for _, source := range cfg.ICS {
+		feeds = append(feeds, &calendarFeed{
+			source: &calendarSource{ID: source.URL, Project: source.Project},
+			fetch: func() ([]*calendar.Event, error) {
+				return listICSEvents(cfg, source, start, end)
+			},
+		})
+	}
+	for _, source := range cfg.CalDAV {
+		feeds = append(feeds, &calendarFeed{
+			source: &calendarSource{ID: source.URL, Project: source.Project},
+			fetch: func() ([]*calendar.Event, error) {
+				return listCalDAVEvents(cfg, source, start, end)
+			},
+		})
+	}
+
+	for _, source := range cfg.Outlook {
+		feeds = append(feeds, &calendarFeed{
+			source: &calendarSource{ID: "outlook:" + source.CalendarID, Project: source.Project},
+			fetch: func() ([]*calendar.Event, error) {
+				return listOutlookEvents(source, start, end)
+			},
+		})
+	}
+
+	entries := []*calendarEntry{}
+	timeOff := []*Row{}
+	// the same event can be on more than one calendar, the first calendar
+	// it is found on wins
+	seen := map[string]bool{}
+	for _, feed := range feeds {
+		items, err := feed.fetch()
+		if err != nil {
+			return nil, err
+		}
+		items = slices.DeleteFunc(items, func(item *calendar.Event) bool {
+			key := item.ICalUID + "/" + item.Start.DateTime + item.Start.Date
+			if seen[key] {
+				return true
+			}
+			seen[key] = true
+			return false
+		})
+		calEntries, calTimeOff, err := calendarEntries(cfg, feed.source, items, start)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, calEntries...)
+		timeOff = append(timeOff, calTimeOff...)
+	}
+
+	rows := adjustOverlaps(entries, overlap.Policy(cfg.OverlapPolicy))
+	if cfg.VerifyJira {
+		err = verifyJiraIDs(rows)
+		if err != nil {
+			return nil, fmt.Errorf("verify linked jira issues: %w", err)
+		}
+	}
+
+	return append(timeOff, rows...), nil
+}
+
+// calendarFeed is a calendar that events are read from. Events from ICS,
+// CalDAV and Outlook calendars are converted to Google's event type so every
+// calendar is categorised the same way.
+type calendarFeed struct {
+	source *calendarSource
+	fetch  func() ([]*calendar.Event, error)
+}
+
+// timeOffRow creates a row for an event matching an all day rule. Timed out
+// of office events are clipped to day and only count as a full day off if
+// they cover the length of a workday.
+func timeOffRow(cfg *calendarConfig, rule *allDayRule, item *calendar.Event, day time.Time) (*Row, error) {
+	workday := cfg.WorkdayHours.Or(8 * time.Hour)
+	hours := workday
+	if item.Start.DateTime != "" && item.End.DateTime != "" {
+		start, err := time.Parse(time.RFC3339, item.Start.DateTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date format for start: %w", err)
+		}
+		end, err := time.Parse(time.RFC3339, item.End.DateTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date format for end: %w", err)
+		}
+		start = maxTime(start.In(day.Location()), startOfDay(day))
+		end = minTime(end.In(day.Location()), endOfDay(day))
+		hours = min(end.Sub(start), workday)
+	}
+	return &Row{
+		Date:        day,
+		Project:     rule.Project,
+		Hours:       hours,
+		Description: item.Summary,
+		TimeOff:     hours >= workday,
+	}, nil
+}
+
+// calendarEntry is a row built from a timed event along with the event's
+// original times.
+type calendarEntry struct {
+	row       *Row
+	start     time.Time
+	end       time.Time
+	organizer bool
+}
+
+// adjustOverlaps sets the hours of each row so that time where events overlap
+// is only counted once.
+func adjustOverlaps(entries []*calendarEntry, policy overlap.Policy) []*Row {
+	intervals := make([]overlap.Interval, len(entries))
+	for i, e := range entries {
+		intervals[i] = overlap.Interval{
+			Start:     e.start,
+			End:       e.end,
+			Organizer: e.organizer,
+		}
+	}
+	adjusted := overlap.Adjust(intervals, policy)
+
+	rows := make([]*Row, len(entries))
+	for i, e := range entries {
+		e.row.Hours = adjusted[i]
+		e.row.Explanation = fmt.Sprintf(
+			"%s-%s (%s), adjusted to %s",
+			e.start.Format(time.Kitchen),
+			e.end.Format(time.Kitchen),
+			e.end.Sub(e.start),
+			adjusted[i],
+		)
+		rows[i] = e.row
+	}
+	return rows
+}
+
+// listCalendarEvents returns every event in the calendar between start and
+// end, following NextPageToken.
+func listCalendarEvents(calendarService *calendar.Service, calendarID string, start, end time.Time) ([]*calendar.Event, error) {
+	items := []*calendar.Event{}
+	err := calendarService.Events.List(calendarID).
 		ShowDeleted(false).
 		SingleEvents(true).
 		TimeMin(start.Format(time.RFC3339)).
 		TimeMax(end.Format(time.RFC3339)).
-		MaxResults(100).
+		MaxResults(250).
 		OrderBy("startTime").
-		Do()
+		Pages(context.Background(), func(events *calendar.Events) error {
+			items = append(items, events.Items...)
+			return nil
+		})
 	if err != nil {
-		return nil, fmt.Errorf("unable to retrieve next ten of the user's events: %w", err)
+		return nil, fmt.Errorf("unable to retrieve events from calendar %s: %w", calendarID, err)
 	}
+	return items, nil
+}
 
-	rows := []*Row{}
-	for _, item := range events.Items {
-		if item.Start.Date != "" || item.End.Date != "" {
+// calendarEntries converts timed events to entries and all day or out of
+// office events that match an all day rule to time off rows for day.
+func calendarEntries(cfg *calendarConfig, source *calendarSource, items []*calendar.Event, day time.Time) ([]*calendarEntry, []*Row, error) {
+	entries := []*calendarEntry{}
+	timeOff := []*Row{}
+	dayStart := startOfDay(day)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	for _, item := range items {
+		if item.Start.Date != "" || item.End.Date != "" || item.EventType == "outOfOffice" {
+			for _, rule := range cfg.AllDayRules {
+				if !rule.matches(item) {
+					continue
+				}
+				row, err := timeOffRow(cfg, rule, item, day)
+				if err != nil {
+					return nil, nil, err
+				}
+				timeOff = append(timeOff, row)
+				break
+			}
+			continue
+		}
+		if status := selfResponseStatus(item); status != "" && !slices.Contains(cfg.IncludeResponses, status) {
 			continue
 		}
 		start, err := time.Parse(time.RFC3339, item.Start.DateTime)
 		if err != nil {
-			return nil, fmt.Errorf("invalid date format for start: %w", err)
+			return nil, nil, fmt.Errorf("invalid date format for start: %w", err)
 		}
 		end, err := time.Parse(time.RFC3339, item.End.DateTime)
 		if err != nil {
-			return nil, fmt.Errorf("invalid date format for end: %w", err)
+			return nil, nil, fmt.Errorf("invalid date format for end: %w", err)
+		}
+		start = start.In(day.Location())
+		end = end.In(day.Location())
+		// events that cross midnight are only credited their part of the day
+		if start.Before(dayStart) {
+			start = dayStart
+		}
+		if end.After(dayEnd) {
+			end = dayEnd
+		}
+		if end.Before(start) {
+			continue
 		}
 
-		project := "Meetings - "
-		description := item.Summary
+		project, description := categorizeEvent(cfg, source, item)
 
-		if strings.Contains(item.Summary, "Standup") {
-			project = "Meetings - Daily Standup"
-			description = ""
-		} else if strings.Contains(item.Summary, "Sprint Demo") {
-			project = "Meetings - Sprint Demo"
-			description = ""
-		} else if strings.Contains(item.Summary, "Backlog Refinement") {
-			project = "Meetings - Backlog Refinement"
-			description = ""
+		jiraID := eventJiraID(item)
+		if jiraID != "" && description == item.Summary {
+			_, description = splitJiraID(item.Summary)
 		}
 
-		rows = append(rows, &Row{
-			Date:        start,
-			Project:     project,
-			Hours:       end.Sub(start),
-			Description: description,
+		entries = append(entries, &calendarEntry{
+			row: &Row{
+				Date:        start,
+				Project:     project,
+				Hours:       end.Sub(start),
+				JiraID:      jiraID,
+				Description: description,
+			},
+			start:     start,
+			end:       end,
+			organizer: item.Organizer != nil && item.Organizer.Self,
 		})
 	}
 
-	return rows, nil
+	return entries, timeOff, nil
+}
+
+// categorizeEvent returns the project and description for a timed event.
+// Configured rules are checked first, then solo events and finally the
+// default rules.
+func categorizeEvent(cfg *calendarConfig, source *calendarSource, item *calendar.Event) (string, string) {
+	internal := internalDomains(cfg, item)
+	for _, rule := range cfg.Rules {
+		if rule.matches(item, internal) {
+			return rule.Project, ruleDescription(rule, item)
+		}
+	}
+	if isSoloEvent(item) {
+		return cfg.SoloProject, item.Summary
+	}
+	for _, rule := range defaultEventRules {
+		if rule.matches(item, internal) {
+			return rule.Project, ruleDescription(rule, item)
+		}
+	}
+	return source.Project, item.Summary
+}
+
+func ruleDescription(rule *eventRule, item *calendar.Event) string {
+	if rule.HideSummary {
+		return ""
+	}
+	return item.Summary
+}
+
+// selfResponseStatus returns the RSVP status of the authenticated user or an
+// empty string if they aren't in the attendee list.
+func selfResponseStatus(item *calendar.Event) string {
+	for _, attendee := range item.Attendees {
+		if attendee.Self {
+			return attendee.ResponseStatus
+		}
+	}
+	return ""
+}
+
+// isSoloEvent reports whether the event has no attendees other than the
+// authenticated user. Resources like meeting rooms aren't counted.
+func isSoloEvent(item *calendar.Event) bool {
+	for _, attendee := range item.Attendees {
+		if attendee.Self || attendee.Resource {
+			continue
+		}
+		return false
+	}
+	return true
 }
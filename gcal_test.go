@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abibby/what-it-do/overlap"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestCalendarEntriesClipsToDay(t *testing.T) {
+	loc := time.FixedZone("EST", -5*60*60)
+	day := time.Date(2024, time.November, 12, 0, 0, 0, 0, loc)
+	event := func(summary, start, end string) *calendar.Event {
+		return &calendar.Event{
+			Summary: summary,
+			Start:   &calendar.EventDateTime{DateTime: start},
+			End:     &calendar.EventDateTime{DateTime: end},
+		}
+	}
+	items := []*calendar.Event{
+		event("Release", "2024-11-11T23:00:00-05:00", "2024-11-12T01:00:00-05:00"),
+		event("Incident review", "2024-11-12T00:30:00-05:00", "2024-11-12T01:30:00-05:00"),
+		event("Night shift", "2024-11-12T23:30:00-05:00", "2024-11-13T02:00:00-05:00"),
+		event("Tomorrow", "2024-11-13T09:00:00-05:00", "2024-11-13T10:00:00-05:00"),
+	}
+	entries, _, err := calendarEntries(&calendarConfig{}, &calendarSource{Project: "Meetings"}, items, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := adjustOverlaps(entries, overlap.Split)
+	want := []struct {
+		description string
+		hours       time.Duration
+	}{
+		{"Release", 45 * time.Minute},
+		{"Incident review", 45 * time.Minute},
+		{"Night shift", 30 * time.Minute},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("expected %d rows, got %d", len(want), len(rows))
+	}
+	for i, w := range want {
+		if rows[i].Description != w.description || rows[i].Hours != w.hours {
+			t.Errorf("row %d: expected %s %s, got %s %s", i, w.description, w.hours, rows[i].Description, rows[i].Hours)
+		}
+		if rows[i].Date.Before(day) {
+			t.Errorf("row %d: expected a date on the report day, got %s", i, rows[i].Date)
+		}
+	}
+}
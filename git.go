@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/abibby/what-it-do/config"
+	"github.com/abibby/what-it-do/gitlog"
+)
+
+// gitConfig is the git section of the config.
+type gitConfig struct {
+	// Repositories are paths to local repositories, they may start with ~
+	// and contain glob patterns like ~/code/*.
+	Repositories []string `json:"repositories"`
+	// Emails are the author emails to count commits for, it defaults to the
+	// global git user.email.
+	Emails []string `json:"emails"`
+	// MaxGap is the longest time between commits that is counted as work,
+	// defaults to 2h.
+	MaxGap config.Duration `json:"max_gap"`
+	// FirstCommit is the time credited to the first commit of a session,
+	// defaults to 30m.
+	FirstCommit config.Duration `json:"first_commit"`
+}
+
+func getGitCommits(start, end time.Time) ([]*Row, error) {
+	cfg := &gitConfig{}
+	err := config.Section("git", cfg)
+	if err != nil {
+		return nil, err
+	}
+	return gitRows(context.Background(), cfg, start, end)
+}
+
+// gitRows groups the commits between start and end by jira key, falling back
+// to the repository for commits without one.
+func gitRows(ctx context.Context, cfg *gitConfig, start, end time.Time) ([]*Row, error) {
+	repos, err := gitlog.ExpandRepositories(cfg.Repositories)
+	if err != nil {
+		return nil, err
+	}
+
+	emails := cfg.Emails
+	if len(emails) == 0 {
+		email, err := gitlog.UserEmail(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("no emails in the git config and git user.email could not be read: %w", err)
+		}
+		emails = []string{email}
+	}
+
+	commits := []*gitlog.Commit{}
+	for _, repo := range repos {
+		repoCommits, err := gitlog.Log(ctx, repo, emails, start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, commit := range repoCommits {
+			commit.Date = commit.Date.In(start.Location())
+		}
+		commits = append(commits, repoCommits...)
+	}
+
+	durations := gitlog.Estimate(commits, cfg.MaxGap.Or(2*time.Hour), cfg.FirstCommit.Or(30*time.Minute))
+
+	rows := []*Row{}
+	rowsByKey := map[string]*Row{}
+	slices.SortFunc(commits, func(a, b *gitlog.Commit) int {
+		return a.Date.Compare(b.Date)
+	})
+	for _, commit := range commits {
+		jiraID := jiraRE.FindString(commit.Branch())
+		subjectJiraID, description := splitJiraID(commit.Subject)
+		if jiraID == "" {
+			jiraID = subjectJiraID
+		}
+		if jiraID == "" {
+			jiraID = jiraRE.FindString(commit.Body)
+		}
+
+		key := jiraID
+		if key == "" {
+			key = commit.Repository
+			description = filepath.Base(commit.Repository) + ": " + description
+		}
+
+		row, ok := rowsByKey[key]
+		if !ok {
+			row = &Row{
+				Date:        start,
+				Project:     "Technical - ",
+				SubCategory: "Implementation",
+				JiraID:      jiraID,
+				Description: description,
+			}
+			rowsByKey[key] = row
+			rows = append(rows, row)
+		}
+		row.Hours += durations[commit]
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGitRows(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	loc := time.FixedZone("EST", -5*60*60)
+	day := time.Date(2024, time.November, 12, 0, 0, 0, 0, loc)
+	repo := filepath.Join(t.TempDir(), "app")
+	err := os.Mkdir(repo, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	git := func(env []string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(), env...)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	commit := func(email string, hour, minute int, message string) {
+		t.Helper()
+		d := day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute).Format(time.RFC3339)
+		git([]string{
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=" + email, "GIT_AUTHOR_DATE=" + d,
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=" + email, "GIT_COMMITTER_DATE=" + d,
+		}, "commit", "-q", "--allow-empty", "-m", message)
+	}
+
+	git(nil, "init", "-q", "-b", "main")
+	commit("me@example.com", -8, 0, "Initial commit")
+	git(nil, "checkout", "-q", "-b", "PD-7-search")
+	commit("me@example.com", 9, 0, "Add search box")
+	commit("other@example.com", 9, 20, "Review fixes")
+	commit("me@example.com", 9, 40, "PD-8: Wire up search")
+	git(nil, "checkout", "-q", "main")
+	commit("me@example.com", 14, 0, "Fix typo\n\nRefs PD-9")
+	commit("me@example.com", 14, 20, "Update readme")
+
+	rows, err := gitRows(context.Background(), &gitConfig{
+		Repositories: []string{filepath.Join(filepath.Dir(repo), "*")},
+		Emails:       []string{"me@example.com"},
+	}, startOfDay(day), endOfDay(day))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the branch's key wins over the one in the subject, commits after a gap
+	// longer than 2h start a new 30m session
+	want := []Row{
+		{JiraID: "PD-7", Description: "Add search box", Hours: 30*time.Minute + 40*time.Minute},
+		{JiraID: "PD-9", Description: "Fix typo", Hours: 30 * time.Minute},
+		{JiraID: "", Description: "app: Update readme", Hours: 20 * time.Minute},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("expected %d rows, got %d: %+v", len(want), len(rows), rows)
+	}
+	for i, row := range rows {
+		if row.JiraID != want[i].JiraID || row.Description != want[i].Description || row.Hours != want[i].Hours ||
+			!row.Date.Equal(day) || row.SubCategory != "Implementation" {
+			t.Errorf("row %d: expected %+v, got %+v", i, want[i], *row)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/abibby/what-it-do/config"
+	"github.com/abibby/what-it-do/ezoauth"
+	"github.com/abibby/what-it-do/github"
+	"golang.org/x/oauth2"
+)
+
+func getGitHubActivity(start, end time.Time) ([]*Row, error) {
+	ghClient, err := getGitHubClient()
+	if err != nil {
+		return nil, err
+	}
+	return gitHubActivity(ghClient, start, end)
+}
+
+// gitHubActivity builds rows for the pull requests the signed in user
+// reviewed or pushed to between start and end.
+func gitHubActivity(ghClient *github.Client, start, end time.Time) ([]*Row, error) {
+	u, err := ghClient.CurrentUser()
+	if err != nil {
+		return nil, fmt.Errorf("get self: %w", err)
+	}
+
+	updated := fmt.Sprintf("updated:%s..%s", start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339))
+
+	rows := []*Row{}
+
+	reviewed, err := ghClient.SearchPullRequests(fmt.Sprintf("involves:%s -author:%s %s", u.Login, u.Login, updated))
+	if err != nil {
+		return nil, fmt.Errorf("search reviewed pull requests: %w", err)
+	}
+	for issue := range reviewed.All() {
+		owner, repo := issue.Repository()
+		ok, err := didReviewGitHub(ghClient, owner, repo, issue.Number, u, start, end)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		row, err := gitHubRow(ghClient, owner, repo, issue.Number, start, "Code Review")
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	if err := reviewed.AllError(); err != nil {
+		return nil, fmt.Errorf("search reviewed pull requests: %w", err)
+	}
+
+	authored, err := ghClient.SearchPullRequests(fmt.Sprintf("author:%s %s", u.Login, updated))
+	if err != nil {
+		return nil, fmt.Errorf("search authored pull requests: %w", err)
+	}
+	for issue := range authored.All() {
+		owner, repo := issue.Repository()
+		ok, err := didCommitGitHub(ghClient, owner, repo, issue, u, start, end)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		row, err := gitHubRow(ghClient, owner, repo, issue.Number, start, "Implementation")
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	if err := authored.AllError(); err != nil {
+		return nil, fmt.Errorf("search authored pull requests: %w", err)
+	}
+
+	return rows, nil
+}
+
+// gitHubRow builds a row for a pull request. The jira key is taken from the
+// title, falling back to the branch name.
+func gitHubRow(ghClient *github.Client, owner, repo string, number int, date time.Time, subCategory string) (*Row, error) {
+	pr, err := ghClient.GetPullRequest(owner, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("get pull request %s/%s#%d: %w", owner, repo, number, err)
+	}
+
+	jiraID, description := splitJiraID(pr.Title)
+	if jiraID == "" && pr.Head != nil {
+		jiraID = jiraRE.FindString(pr.Head.Ref)
+	}
+
+	return &Row{
+		Date:        date,
+		Project:     "Technical - ",
+		SubCategory: subCategory,
+		JiraID:      jiraID,
+		Description: description,
+	}, nil
+}
+
+// didReviewGitHub reports whether the user submitted a review or commented on
+// the pull request between start and end.
+func didReviewGitHub(ghClient *github.Client, owner, repo string, number int, user *github.User, start, end time.Time) (bool, error) {
+	reviews, err := ghClient.ListReviews(owner, repo, number)
+	if err != nil {
+		return false, fmt.Errorf("list reviews %s/%s#%d: %w", owner, repo, number, err)
+	}
+	for review := range reviews.All() {
+		if review.User == nil || review.User.ID != user.ID {
+			continue
+		}
+		if start.Before(review.SubmittedAt) && end.After(review.SubmittedAt) {
+			return true, nil
+		}
+	}
+	if err := reviews.AllError(); err != nil {
+		return false, fmt.Errorf("list reviews %s/%s#%d: %w", owner, repo, number, err)
+	}
+
+	comments, err := ghClient.ListIssueComments(owner, repo, number, start)
+	if err != nil {
+		return false, fmt.Errorf("list comments %s/%s#%d: %w", owner, repo, number, err)
+	}
+	for comment := range comments.All() {
+		if comment.User == nil || comment.User.ID != user.ID {
+			continue
+		}
+		if start.Before(comment.CreatedAt) && end.After(comment.CreatedAt) {
+			return true, nil
+		}
+	}
+	if err := comments.AllError(); err != nil {
+		return false, fmt.Errorf("list comments %s/%s#%d: %w", owner, repo, number, err)
+	}
+	return false, nil
+}
+
+// didCommitGitHub reports whether the user opened the pull request or
+// authored one of its commits between start and end.
+func didCommitGitHub(ghClient *github.Client, owner, repo string, issue *github.Issue, user *github.User, start, end time.Time) (bool, error) {
+	if start.Before(issue.CreatedAt) && end.After(issue.CreatedAt) {
+		return true, nil
+	}
+
+	commits, err := ghClient.ListPullRequestCommits(owner, repo, issue.Number)
+	if err != nil {
+		return false, fmt.Errorf("list commits %s/%s#%d: %w", owner, repo, issue.Number, err)
+	}
+	for commit := range commits.All() {
+		if commit.Author == nil || commit.Author.ID != user.ID || commit.Commit == nil || commit.Commit.Author == nil {
+			continue
+		}
+		authored := commit.Commit.Author.Date
+		if start.Before(authored) && end.After(authored) {
+			return true, nil
+		}
+	}
+	if err := commits.AllError(); err != nil {
+		return false, fmt.Errorf("list commits %s/%s#%d: %w", owner, repo, issue.Number, err)
+	}
+	return false, nil
+}
+
+// gitHubConfig is the optional github section of the config.
+type gitHubConfig struct {
+	// BaseURL is the REST API root, set it for GitHub Enterprise or a fake
+	// server.
+	BaseURL string `json:"base_url"`
+}
+
+func readGitHubConfig() (*gitHubConfig, error) {
+	ghConfig := &gitHubConfig{}
+	err := config.Section("github", ghConfig)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return ghConfig, nil
+}
+
+func gitHubOAuthConfig() (*ezoauth.Config, error) {
+	oauthConfig, err := ezoauth.ReadConfigJSON(config.Path("github_creds.json"))
+	if err != nil {
+		return nil, err
+	}
+	oauthConfig.Endpoint = oauth2.Endpoint{
+		AuthURL:       "https://github.com/login/oauth/authorize",
+		TokenURL:      "https://github.com/login/oauth/access_token",
+		DeviceAuthURL: "https://github.com/login/device/code",
+	}
+	return &ezoauth.Config{
+		Name:        "github",
+		OAuthConfig: oauthConfig,
+	}, nil
+}
+
+func getGitHubClient() (*github.Client, error) {
+	ctx := context.Background()
+
+	ghConfig, err := readGitHubConfig()
+	if err != nil {
+		return nil, err
+	}
+	ezconfig, err := gitHubOAuthConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := ezconfig.Client(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not start github client: %w", err)
+	}
+
+	return github.NewClient(client, ghConfig.BaseURL), nil
+}
@@ -0,0 +1,124 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const DefaultBaseURL = "https://api.github.com"
+
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewClient creates a GitHub REST API client. If baseURL is empty
+// DefaultBaseURL is used, GitHub Enterprise and fake servers can pass their
+// own API root.
+func NewClient(c *http.Client, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		httpClient: c,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+func (c *Client) CurrentUser() (*User, error) {
+	u := &User{}
+	_, err := c.request(http.MethodGet, "/user", nil, u)
+	return u, err
+}
+
+// SearchPullRequests runs an issue search, "is:pr" is added to the query.
+func (c *Client) SearchPullRequests(query string) (*PaginatedResponse[*Issue], error) {
+	return list[*Issue](c, "/search/issues", url.Values{
+		"q":        {"is:pr " + query},
+		"per_page": {"100"},
+	}, true)
+}
+
+func (c *Client) GetPullRequest(owner, repo string, number int) (*PullRequest, error) {
+	pr := &PullRequest{}
+	_, err := c.request(http.MethodGet, fmt.Sprintf("/repos/%s/%s/pulls/%d", url.PathEscape(owner), url.PathEscape(repo), number), nil, pr)
+	return pr, err
+}
+
+func (c *Client) ListReviews(owner, repo string, number int) (*PaginatedResponse[*Review], error) {
+	return list[*Review](c, fmt.Sprintf("/repos/%s/%s/pulls/%d/reviews", url.PathEscape(owner), url.PathEscape(repo), number), url.Values{
+		"per_page": {"100"},
+	}, false)
+}
+
+func (c *Client) ListIssueComments(owner, repo string, number int, since time.Time) (*PaginatedResponse[*IssueComment], error) {
+	q := url.Values{
+		"per_page": {"100"},
+	}
+	if !since.IsZero() {
+		q.Set("since", since.UTC().Format(time.RFC3339))
+	}
+	return list[*IssueComment](c, fmt.Sprintf("/repos/%s/%s/issues/%d/comments", url.PathEscape(owner), url.PathEscape(repo), number), q, false)
+}
+
+func (c *Client) ListPullRequestCommits(owner, repo string, number int) (*PaginatedResponse[*Commit], error) {
+	return list[*Commit](c, fmt.Sprintf("/repos/%s/%s/pulls/%d/commits", url.PathEscape(owner), url.PathEscape(repo), number), url.Values{
+		"per_page": {"100"},
+	}, false)
+}
+
+func (c *Client) request(method, p string, query url.Values, v any) (*http.Response, error) {
+	u := c.baseURL + p
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return c.rawRequest(method, u, v)
+}
+
+func (c *Client) rawRequest(method, url string, v any) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		errResp := &ErrorResponse{
+			response: resp,
+		}
+		b, err := io.ReadAll(resp.Body)
+		if err == nil {
+			_ = json.Unmarshal(b, errResp)
+		}
+		return resp, errResp
+	}
+
+	return resp, json.NewDecoder(resp.Body).Decode(v)
+}
+
+type ErrorResponse struct {
+	Message          string `json:"message"`
+	DocumentationURL string `json:"documentation_url"`
+
+	response *http.Response
+}
+
+func (e *ErrorResponse) Error() string {
+	base := "github request failed: "
+	if e.Message == "" {
+		return base + e.response.Status
+	}
+	return fmt.Sprintf("%s%d %s", base, e.response.StatusCode, e.Message)
+}
@@ -0,0 +1,94 @@
+package github
+
+import (
+	"iter"
+	"net/http"
+	"net/url"
+	"regexp"
+)
+
+// PaginatedResponse follows the rel="next" Link header returned by list and
+// search endpoints.
+type PaginatedResponse[T any] struct {
+	Values []T
+
+	next    string
+	search  bool
+	client  *Client
+	iterErr error
+}
+
+type searchResponse[T any] struct {
+	TotalCount int `json:"total_count"`
+	Items      []T `json:"items"`
+}
+
+func list[T any](c *Client, p string, query url.Values, search bool) (*PaginatedResponse[T], error) {
+	u := c.baseURL + p
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	r := &PaginatedResponse[T]{
+		client: c,
+		search: search,
+	}
+	err := r.fetch(u)
+	return r, err
+}
+
+func (r *PaginatedResponse[T]) fetch(u string) error {
+	var resp *http.Response
+	var err error
+	if r.search {
+		page := &searchResponse[T]{}
+		resp, err = r.client.rawRequest(http.MethodGet, u, page)
+		r.Values = page.Items
+	} else {
+		resp, err = r.client.rawRequest(http.MethodGet, u, &r.Values)
+	}
+	if err != nil {
+		return err
+	}
+	r.next = nextLink(resp.Header.Get("Link"))
+	return nil
+}
+
+func (r *PaginatedResponse[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		page := r
+		for {
+			for _, v := range page.Values {
+				if !yield(v) {
+					return
+				}
+			}
+			if page.next == "" {
+				return
+			}
+
+			next := page.next
+			page = &PaginatedResponse[T]{
+				client: r.client,
+				search: r.search,
+			}
+			err := page.fetch(next)
+			if err != nil {
+				r.iterErr = err
+				return
+			}
+		}
+	}
+}
+func (r *PaginatedResponse[T]) AllError() error {
+	return r.iterErr
+}
+
+var nextLinkRE = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+func nextLink(header string) string {
+	m := nextLinkRE.FindStringSubmatch(header)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
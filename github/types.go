@@ -0,0 +1,94 @@
+package github
+
+import (
+	"strings"
+	"time"
+)
+
+type User struct {
+	Login string `json:"login"`
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Issue is a search result. Pull requests are returned as issues with
+// PullRequest set.
+type Issue struct {
+	Number        int               `json:"number"`
+	Title         string            `json:"title"`
+	State         string            `json:"state"`
+	HTMLURL       string            `json:"html_url"`
+	RepositoryURL string            `json:"repository_url"`
+	User          *User             `json:"user"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	PullRequest   *IssuePullRequest `json:"pull_request"`
+}
+
+type IssuePullRequest struct {
+	URL      string     `json:"url"`
+	MergedAt *time.Time `json:"merged_at"`
+}
+
+// Repository returns the owner and name of the repository the issue belongs
+// to.
+func (i *Issue) Repository() (owner, repo string) {
+	parts := strings.Split(strings.TrimSuffix(i.RepositoryURL, "/"), "/")
+	if len(parts) < 2 {
+		return "", ""
+	}
+	return parts[len(parts)-2], parts[len(parts)-1]
+}
+
+type Ref struct {
+	Ref   string `json:"ref"`
+	Label string `json:"label"`
+	SHA   string `json:"sha"`
+}
+
+type PullRequest struct {
+	Number    int        `json:"number"`
+	Title     string     `json:"title"`
+	State     string     `json:"state"`
+	HTMLURL   string     `json:"html_url"`
+	User      *User      `json:"user"`
+	Head      *Ref       `json:"head"`
+	Base      *Ref       `json:"base"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	MergedAt  *time.Time `json:"merged_at"`
+}
+
+type Review struct {
+	ID          int64     `json:"id"`
+	User        *User     `json:"user"`
+	State       string    `json:"state"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+type IssueComment struct {
+	ID        int64     `json:"id"`
+	User      *User     `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CommitAuthor struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+type CommitDetails struct {
+	Message string        `json:"message"`
+	Author  *CommitAuthor `json:"author"`
+}
+
+type Commit struct {
+	SHA    string         `json:"sha"`
+	Commit *CommitDetails `json:"commit"`
+	// Author is the GitHub account linked to the commit author, it is nil if
+	// the email isn't associated with an account.
+	Author *User `json:"author"`
+}
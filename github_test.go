@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abibby/what-it-do/github"
+)
+
+// fakeGitHub serves a user "me" with one reviewed and two authored pull
+// requests on the day, the second authored pull request is on another search
+// page. Pull request 2 was reviewed the day before and isn't reported.
+func fakeGitHub(t *testing.T, day time.Time) *httptest.Server {
+	t.Helper()
+	at := func(hour int) string {
+		return day.Add(time.Duration(hour) * time.Hour).UTC().Format(time.RFC3339)
+	}
+	me := map[string]any{"login": "me", "id": 1}
+	other := map[string]any{"login": "other", "id": 2}
+	issue := func(number int, created string) map[string]any {
+		return map[string]any{
+			"number":         number,
+			"repository_url": "https://api.github.com/repos/acme/app",
+			"created_at":     created,
+			"pull_request":   map[string]any{},
+		}
+	}
+	pulls := map[string]map[string]any{
+		"1": {"number": 1, "title": "PD-12: Fix login", "head": map[string]any{"ref": "fix-login"}},
+		"2": {"number": 2, "title": "Bump dependencies", "head": map[string]any{"ref": "deps"}},
+		"3": {"number": 3, "title": "Add export", "head": map[string]any{"ref": "PD-34-export"}},
+		"4": {"number": 4, "title": "PD-56 Clean up settings", "head": map[string]any{"ref": "cleanup"}},
+	}
+
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	write := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(v)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	mux.HandleFunc("GET /user", func(w http.ResponseWriter, r *http.Request) {
+		write(w, me)
+	})
+	mux.HandleFunc("GET /search/issues", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("q")
+		if !strings.HasPrefix(q, "is:pr ") || !strings.Contains(q, "updated:") {
+			t.Errorf("unexpected query %q", q)
+		}
+		switch {
+		case strings.Contains(q, "involves:me -author:me"):
+			write(w, map[string]any{"items": []any{issue(1, at(-48)), issue(2, at(-48))}})
+		case r.URL.Query().Get("page") == "2":
+			write(w, map[string]any{"items": []any{issue(4, at(-72))}})
+		case strings.Contains(q, "author:me"):
+			next := srv.URL + r.URL.Path + "?" + r.URL.RawQuery + "&page=2"
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next", <%s>; rel="last"`, next, next))
+			write(w, map[string]any{"items": []any{issue(3, at(10))}})
+		default:
+			t.Errorf("unexpected query %q", q)
+			write(w, map[string]any{"items": []any{}})
+		}
+	})
+	mux.HandleFunc("GET /repos/acme/app/pulls/{number}", func(w http.ResponseWriter, r *http.Request) {
+		pr, ok := pulls[r.PathValue("number")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		write(w, pr)
+	})
+	mux.HandleFunc("GET /repos/acme/app/pulls/{number}/reviews", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("number") {
+		case "1":
+			write(w, []any{
+				map[string]any{"user": other, "state": "COMMENTED", "submitted_at": at(9)},
+				map[string]any{"user": me, "state": "APPROVED", "submitted_at": at(11)},
+			})
+		case "2":
+			write(w, []any{map[string]any{"user": me, "state": "APPROVED", "submitted_at": at(-20)}})
+		default:
+			write(w, []any{})
+		}
+	})
+	mux.HandleFunc("GET /repos/acme/app/issues/{number}/comments", func(w http.ResponseWriter, r *http.Request) {
+		write(w, []any{})
+	})
+	mux.HandleFunc("GET /repos/acme/app/pulls/{number}/commits", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("number") {
+		case "4":
+			write(w, []any{
+				map[string]any{"author": me, "commit": map[string]any{"author": map[string]any{"date": at(-70)}}},
+				map[string]any{"author": me, "commit": map[string]any{"author": map[string]any{"date": at(15)}}},
+			})
+		default:
+			write(w, []any{})
+		}
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGitHubActivity(t *testing.T) {
+	day := time.Date(2024, time.November, 12, 0, 0, 0, 0, time.UTC)
+	srv := fakeGitHub(t, day)
+
+	rows, err := gitHubActivity(github.NewClient(srv.Client(), srv.URL), startOfDay(day), endOfDay(day))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Row{
+		{Date: day, Project: "Technical - ", SubCategory: "Code Review", JiraID: "PD-12", Description: "Fix login"},
+		{Date: day, Project: "Technical - ", SubCategory: "Implementation", JiraID: "PD-34", Description: "Add export"},
+		{Date: day, Project: "Technical - ", SubCategory: "Implementation", JiraID: "PD-56", Description: "Clean up settings"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("expected %d rows, got %d: %+v", len(want), len(rows), rows)
+	}
+	for i, row := range rows {
+		if !row.Date.Equal(want[i].Date) || row.Project != want[i].Project || row.SubCategory != want[i].SubCategory ||
+			row.JiraID != want[i].JiraID || row.Description != want[i].Description {
+			t.Errorf("row %d: expected %+v, got %+v", i, want[i], *row)
+		}
+	}
+}
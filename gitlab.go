@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/abibby/what-it-do/config"
+	"github.com/abibby/what-it-do/ezoauth"
+	"github.com/abibby/what-it-do/gitlab"
+	"golang.org/x/oauth2"
+)
+
+func getGitLabActivity(start, end time.Time) ([]*Row, error) {
+	glClient, err := getGitLabClient()
+	if err != nil {
+		return nil, err
+	}
+	u, err := glClient.CurrentUser()
+	if err != nil {
+		return nil, fmt.Errorf("get self: %w", err)
+	}
+
+	// after and before are exclusive dates
+	events, err := glClient.ListEvents(&gitlab.ListEventsOptions{
+		After:  start.AddDate(0, 0, -1),
+		Before: end.AddDate(0, 0, 1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+
+	type mergeRequestKey struct {
+		projectID int
+		iid       int
+	}
+	type branchKey struct {
+		projectID int
+		ref       string
+	}
+
+	rows := []*Row{}
+	reviewed := map[mergeRequestKey]bool{}
+	pushed := map[branchKey]bool{}
+
+	for event := range events.All() {
+		if event.CreatedAt.Before(start) || event.CreatedAt.After(end) {
+			continue
+		}
+
+		switch event.ActionName {
+		case "approved", "commented on":
+			key := mergeRequestKey{projectID: event.ProjectID}
+			if event.TargetType == "MergeRequest" {
+				key.iid = event.TargetIID
+			} else if event.Note != nil && event.Note.NoteableType == "MergeRequest" {
+				key.iid = event.Note.NoteableIID
+			} else {
+				continue
+			}
+			if reviewed[key] {
+				continue
+			}
+			reviewed[key] = true
+
+			mr, err := glClient.GetMergeRequest(key.projectID, key.iid)
+			if err != nil {
+				return nil, fmt.Errorf("get merge request %d!%d: %w", key.projectID, key.iid, err)
+			}
+			if mr.Author != nil && mr.Author.ID == u.ID {
+				continue
+			}
+
+			jiraID, description := splitJiraID(mr.Title)
+			if jiraID == "" {
+				jiraID = jiraRE.FindString(mr.SourceBranch)
+			}
+			rows = append(rows, &Row{
+				Date:        start,
+				Project:     "Technical - ",
+				SubCategory: "Code Review",
+				JiraID:      jiraID,
+				Description: description,
+			})
+
+		case "pushed to", "pushed new":
+			if event.PushData == nil || event.PushData.RefType != "branch" {
+				continue
+			}
+			key := branchKey{projectID: event.ProjectID, ref: event.PushData.Ref}
+			if pushed[key] {
+				continue
+			}
+			pushed[key] = true
+
+			jiraID := jiraRE.FindString(event.PushData.Ref)
+			commitJiraID, description := splitJiraID(event.PushData.CommitTitle)
+			if jiraID == "" {
+				jiraID = commitJiraID
+			}
+			rows = append(rows, &Row{
+				Date:        start,
+				Project:     "Technical - ",
+				SubCategory: "Implementation",
+				JiraID:      jiraID,
+				Description: description,
+			})
+		}
+	}
+	if err := events.AllError(); err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+
+	return rows, nil
+}
+
+// gitLabConfig is the gitlab section of the config. If Token is empty the OAuth
+// application in gitlab_creds.json is used instead.
+type gitLabConfig struct {
+	BaseURL string `json:"base_url"`
+	// Token is a personal access token with the read_api scope.
+	Token string `json:"token"`
+}
+
+func gitLabConfigured() bool {
+	return config.HasSection("gitlab") || config.Exists("gitlab_creds.json")
+}
+
+func readGitLabConfig() (*gitLabConfig, error) {
+	glConfig := &gitLabConfig{}
+	err := config.Section("gitlab", glConfig)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if glConfig.BaseURL == "" {
+		glConfig.BaseURL = gitlab.DefaultBaseURL
+	}
+	return glConfig, nil
+}
+
+func gitLabOAuthConfig(baseURL string) (*ezoauth.Config, error) {
+	oauthConfig, err := ezoauth.ReadConfigJSON(config.Path("gitlab_creds.json"))
+	if err != nil {
+		return nil, err
+	}
+	oauthConfig.Endpoint = oauth2.Endpoint{
+		AuthURL:  baseURL + "/oauth/authorize",
+		TokenURL: baseURL + "/oauth/token",
+	}
+	return &ezoauth.Config{
+		Name:        "gitlab",
+		OAuthConfig: oauthConfig,
+	}, nil
+}
+
+func getGitLabClient() (*gitlab.Client, error) {
+	ctx := context.Background()
+
+	glConfig, err := readGitLabConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if glConfig.Token != "" {
+		client := oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{
+			AccessToken: glConfig.Token,
+			TokenType:   "Bearer",
+		}))
+		client.Transport = ezoauth.NewLogRoundTripper("gitlab", client.Transport)
+		return gitlab.NewClient(client, glConfig.BaseURL), nil
+	}
+
+	ezconfig, err := gitLabOAuthConfig(glConfig.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	client, err := ezconfig.Client(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not start gitlab client: %w", err)
+	}
+
+	return gitlab.NewClient(client, glConfig.BaseURL), nil
+}
@@ -0,0 +1,120 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const DefaultBaseURL = "https://gitlab.com"
+
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewClient creates a GitLab REST API client for the instance at baseURL, if
+// it is empty DefaultBaseURL is used. The http client must add either an
+// OAuth or personal access token as a bearer token.
+func NewClient(c *http.Client, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		httpClient: c,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+func (c *Client) CurrentUser() (*User, error) {
+	u := &User{}
+	_, err := c.request(http.MethodGet, "/user", nil, u)
+	return u, err
+}
+
+type ListEventsOptions struct {
+	// After and Before are exclusive and only the date is used.
+	After  time.Time
+	Before time.Time
+	Action string
+}
+
+// ListEvents lists the authenticated user's contribution events.
+func (c *Client) ListEvents(options *ListEventsOptions) (*PaginatedResponse[*Event], error) {
+	q := url.Values{
+		"per_page": {"100"},
+	}
+	if !options.After.IsZero() {
+		q.Set("after", options.After.Format(time.DateOnly))
+	}
+	if !options.Before.IsZero() {
+		q.Set("before", options.Before.Format(time.DateOnly))
+	}
+	if options.Action != "" {
+		q.Set("action", options.Action)
+	}
+	return list[*Event](c, "/events", q)
+}
+
+func (c *Client) GetMergeRequest(projectID, iid int) (*MergeRequest, error) {
+	mr := &MergeRequest{}
+	_, err := c.request(http.MethodGet, fmt.Sprintf("/projects/%d/merge_requests/%d", projectID, iid), nil, mr)
+	return mr, err
+}
+
+func (c *Client) request(method, p string, query url.Values, v any) (*http.Response, error) {
+	u := c.baseURL + "/api/v4" + p
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return c.rawRequest(method, u, v)
+}
+
+func (c *Client) rawRequest(method, url string, v any) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		errResp := &ErrorResponse{
+			response: resp,
+		}
+		b, err := io.ReadAll(resp.Body)
+		if err == nil {
+			_ = json.Unmarshal(b, errResp)
+		}
+		return resp, errResp
+	}
+
+	return resp, json.NewDecoder(resp.Body).Decode(v)
+}
+
+type ErrorResponse struct {
+	Message any    `json:"message"`
+	ErrorID string `json:"error"`
+
+	response *http.Response
+}
+
+func (e *ErrorResponse) Error() string {
+	base := "gitlab request failed: "
+	if e.Message != nil {
+		return fmt.Sprintf("%s%d %v", base, e.response.StatusCode, e.Message)
+	}
+	if e.ErrorID != "" {
+		return fmt.Sprintf("%s%d %s", base, e.response.StatusCode, e.ErrorID)
+	}
+	return base + e.response.Status
+}
@@ -0,0 +1,76 @@
+package gitlab
+
+import (
+	"iter"
+	"net/http"
+	"net/url"
+)
+
+// PaginatedResponse follows GitLab's offset pagination using the X-Next-Page
+// header.
+type PaginatedResponse[T any] struct {
+	Values []T
+
+	nextPage string
+	url      *url.URL
+	client   *Client
+	iterErr  error
+}
+
+func list[T any](c *Client, p string, query url.Values) (*PaginatedResponse[T], error) {
+	u, err := url.Parse(c.baseURL + "/api/v4" + p)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = query.Encode()
+
+	r := &PaginatedResponse[T]{
+		client: c,
+		url:    u,
+	}
+	err = r.fetch(u.String())
+	return r, err
+}
+
+func (r *PaginatedResponse[T]) fetch(u string) error {
+	resp, err := r.client.rawRequest(http.MethodGet, u, &r.Values)
+	if err != nil {
+		return err
+	}
+	r.nextPage = resp.Header.Get("X-Next-Page")
+	return nil
+}
+
+func (r *PaginatedResponse[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		page := r
+		for {
+			for _, v := range page.Values {
+				if !yield(v) {
+					return
+				}
+			}
+			if page.nextPage == "" {
+				return
+			}
+
+			u := *r.url
+			q := u.Query()
+			q.Set("page", page.nextPage)
+			u.RawQuery = q.Encode()
+
+			page = &PaginatedResponse[T]{
+				client: r.client,
+				url:    r.url,
+			}
+			err := page.fetch(u.String())
+			if err != nil {
+				r.iterErr = err
+				return
+			}
+		}
+	}
+}
+func (r *PaginatedResponse[T]) AllError() error {
+	return r.iterErr
+}
@@ -0,0 +1,57 @@
+package gitlab
+
+import "time"
+
+type User struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+}
+
+type PushData struct {
+	CommitCount int    `json:"commit_count"`
+	Action      string `json:"action"`
+	RefType     string `json:"ref_type"`
+	Ref         string `json:"ref"`
+	CommitTitle string `json:"commit_title"`
+}
+
+type Note struct {
+	ID           int    `json:"id"`
+	Body         string `json:"body"`
+	NoteableType string `json:"noteable_type"`
+	NoteableID   int    `json:"noteable_id"`
+	NoteableIID  int    `json:"noteable_iid"`
+}
+
+// Event is a contribution event from the events API.
+//
+// https://docs.gitlab.com/ee/api/events.html
+type Event struct {
+	ID          int       `json:"id"`
+	ProjectID   int       `json:"project_id"`
+	ActionName  string    `json:"action_name"`
+	TargetID    int       `json:"target_id"`
+	TargetIID   int       `json:"target_iid"`
+	TargetType  string    `json:"target_type"`
+	TargetTitle string    `json:"target_title"`
+	CreatedAt   time.Time `json:"created_at"`
+	Author      *User     `json:"author"`
+	PushData    *PushData `json:"push_data"`
+	Note        *Note     `json:"note"`
+}
+
+type MergeRequest struct {
+	ID           int       `json:"id"`
+	IID          int       `json:"iid"`
+	ProjectID    int       `json:"project_id"`
+	Title        string    `json:"title"`
+	State        string    `json:"state"`
+	SourceBranch string    `json:"source_branch"`
+	TargetBranch string    `json:"target_branch"`
+	Author       *User     `json:"author"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	WebURL       string    `json:"web_url"`
+}
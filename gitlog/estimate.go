@@ -0,0 +1,32 @@
+package gitlog
+
+import (
+	"slices"
+	"time"
+)
+
+// Estimate returns the time spent on each commit. Commits are ordered by date
+// and each one is credited with the gap since the previous commit. When the
+// gap is longer than maxGap the commit is assumed to start a new session and
+// is credited with firstCommit instead.
+func Estimate(commits []*Commit, maxGap, firstCommit time.Duration) map[*Commit]time.Duration {
+	sorted := slices.Clone(commits)
+	slices.SortFunc(sorted, func(a, b *Commit) int {
+		return a.Date.Compare(b.Date)
+	})
+
+	result := make(map[*Commit]time.Duration, len(sorted))
+	for i, c := range sorted {
+		if i == 0 {
+			result[c] = firstCommit
+			continue
+		}
+		gap := c.Date.Sub(sorted[i-1].Date)
+		if gap > maxGap {
+			result[c] = firstCommit
+		} else {
+			result[c] = gap
+		}
+	}
+	return result
+}
@@ -0,0 +1,156 @@
+package gitlog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Commit struct {
+	Hash       string
+	Repository string
+	Email      string
+	Date       time.Time
+	// Ref is the ref the commit was reached from e.g. refs/heads/PD-123-fix.
+	Ref     string
+	Subject string
+	Body    string
+}
+
+// Branch returns the commit's ref with the refs/heads/ or refs/remotes/<name>/
+// prefix removed.
+func (c *Commit) Branch() string {
+	ref := c.Ref
+	if b, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+		return b
+	}
+	if b, ok := strings.CutPrefix(ref, "refs/remotes/"); ok {
+		_, b, _ = strings.Cut(b, "/")
+		return b
+	}
+	return ref
+}
+
+// ExpandRepositories resolves a leading ~ and glob patterns, returning the
+// directories that are git repositories.
+func ExpandRepositories(patterns []string) ([]string, error) {
+	home, _ := os.UserHomeDir()
+
+	repos := []string{}
+	for _, pattern := range patterns {
+		if p, ok := strings.CutPrefix(pattern, "~"); ok && home != "" {
+			pattern = filepath.Join(home, p)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid repository pattern %s: %w", pattern, err)
+		}
+		for _, m := range matches {
+			if _, err := os.Stat(filepath.Join(m, ".git")); err != nil {
+				continue
+			}
+			if !slices.Contains(repos, m) {
+				repos = append(repos, m)
+			}
+		}
+	}
+	return repos, nil
+}
+
+const (
+	fieldSep  = "\x1f"
+	recordSep = "\x1e"
+)
+
+// Log returns the commits on any ref of the repository authored by one of
+// emails between since and until. If emails is empty all authors are
+// included.
+func Log(ctx context.Context, repo string, emails []string, since, until time.Time) ([]*Commit, error) {
+	args := []string{
+		"-C", repo,
+		"log",
+		"--all",
+		"--source",
+		"--no-merges",
+		"--since=" + since.Format(time.RFC3339),
+		"--until=" + until.Format(time.RFC3339),
+		"--format=" + strings.Join([]string{"%H", "%ae", "%aI", "%S", "%s", "%b"}, fieldSep) + recordSep,
+	}
+
+	out, err := git(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("git log %s: %w", repo, err)
+	}
+
+	commits := []*Commit{}
+	for _, record := range strings.Split(string(out), recordSep) {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, fieldSep, 6)
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("git log %s: unexpected output %q", repo, record)
+		}
+		if len(emails) > 0 && !containsFold(emails, fields[1]) {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("git log %s: invalid date: %w", repo, err)
+		}
+		// --since and --until use the committer date, rebased commits can
+		// have author dates outside the range.
+		if date.Before(since) || date.After(until) {
+			continue
+		}
+		commits = append(commits, &Commit{
+			Hash:       fields[0],
+			Repository: repo,
+			Email:      fields[1],
+			Date:       date,
+			Ref:        fields[3],
+			Subject:    fields[4],
+			Body:       strings.TrimSpace(fields[5]),
+		})
+	}
+	return commits, nil
+}
+
+// UserEmail returns the globally configured git user.email.
+func UserEmail(ctx context.Context) (string, error) {
+	out, err := git(ctx, "config", "--global", "user.email")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func git(ctx context.Context, args ...string) ([]byte, error) {
+	stderr := &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if stderr.Len() > 0 {
+			return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+func containsFold(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if strings.EqualFold(s, needle) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package gitlog
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+var loc = time.FixedZone("EST", -5*60*60)
+
+func at(day, hour, minute int) time.Time {
+	return time.Date(2024, time.November, day, hour, minute, 0, 0, loc)
+}
+
+type testRepo struct {
+	t   *testing.T
+	dir string
+}
+
+// newTestRepo creates an empty repository at <tmp>/name with the global and
+// system git config ignored.
+func newTestRepo(t *testing.T, name string) *testRepo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	r := &testRepo{t: t, dir: filepath.Join(t.TempDir(), name)}
+	err := os.Mkdir(r.dir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.git(nil, "init", "-q", "-b", "main")
+	return r
+}
+
+func (r *testRepo) git(env []string, args ...string) {
+	r.t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", r.dir}, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+}
+
+// commit adds an empty commit authored and committed by email at date.
+func (r *testRepo) commit(email string, date time.Time, message string) {
+	r.t.Helper()
+	d := date.Format(time.RFC3339)
+	r.git([]string{
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=" + email, "GIT_AUTHOR_DATE=" + d,
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=" + email, "GIT_COMMITTER_DATE=" + d,
+	}, "commit", "-q", "--allow-empty", "-m", message)
+}
+
+func TestLog(t *testing.T) {
+	r := newTestRepo(t, "app")
+	r.commit("me@example.com", at(11, 16, 0), "Initial commit")
+	r.git(nil, "checkout", "-q", "-b", "PD-7-search")
+	r.commit("me@example.com", at(12, 9, 0), "Add search box")
+	r.commit("other@example.com", at(12, 9, 20), "Review fixes")
+	r.git(nil, "checkout", "-q", "main")
+	r.commit("Me@Example.com", at(12, 14, 0), "Fix typo\n\nRefs PD-9\n")
+	r.commit("me@example.com", at(13, 8, 0), "Tomorrow")
+
+	commits, err := Log(context.Background(), r.dir, []string{"me@example.com"}, at(12, 0, 0), at(13, 0, 0).Add(-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		subject, body, ref, branch string
+		date                       time.Time
+	}
+	got := []result{}
+	for _, c := range commits {
+		if c.Repository != r.dir || len(c.Hash) != 40 {
+			t.Errorf("unexpected commit %+v", c)
+		}
+		got = append(got, result{c.Subject, c.Body, c.Ref, c.Branch(), c.Date})
+	}
+	slices.SortFunc(got, func(a, b result) int {
+		return a.date.Compare(b.date)
+	})
+	want := []result{
+		{"Add search box", "", "refs/heads/PD-7-search", "PD-7-search", at(12, 9, 0)},
+		{"Fix typo", "Refs PD-9", "refs/heads/main", "main", at(12, 14, 0)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d commits, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i].subject != want[i].subject || got[i].body != want[i].body || got[i].ref != want[i].ref ||
+			got[i].branch != want[i].branch || !got[i].date.Equal(want[i].date) {
+			t.Errorf("commit %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+
+	all, err := Log(context.Background(), r.dir, nil, at(12, 0, 0), at(13, 0, 0).Add(-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Errorf("expected 3 commits without an email filter, got %d", len(all))
+	}
+}
+
+func TestExpandRepositories(t *testing.T) {
+	a := newTestRepo(t, "a")
+	b := newTestRepo(t, "b")
+	notRepo := t.TempDir()
+
+	repos, err := ExpandRepositories([]string{
+		filepath.Join(filepath.Dir(a.dir), "*"),
+		a.dir,
+		filepath.Join(filepath.Dir(b.dir), "b"),
+		notRepo,
+		filepath.Join(t.TempDir(), "missing", "*"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{a.dir, b.dir}
+	if !slices.Equal(repos, want) {
+		t.Errorf("expected %v, got %v", want, repos)
+	}
+
+	_, err = ExpandRepositories([]string{"[a-"})
+	if err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	testCases := []struct {
+		name  string
+		dates []time.Time
+		want  []time.Duration
+	}{
+		{
+			name:  "single commit",
+			dates: []time.Time{at(12, 9, 0)},
+			want:  []time.Duration{30 * time.Minute},
+		},
+		{
+			name:  "gaps",
+			dates: []time.Time{at(12, 9, 0), at(12, 9, 40), at(12, 11, 40)},
+			want:  []time.Duration{30 * time.Minute, 40 * time.Minute, 2 * time.Hour},
+		},
+		{
+			name:  "new session",
+			dates: []time.Time{at(12, 9, 0), at(12, 14, 0), at(12, 14, 10)},
+			want:  []time.Duration{30 * time.Minute, 30 * time.Minute, 10 * time.Minute},
+		},
+		{
+			name:  "unordered",
+			dates: []time.Time{at(12, 10, 0), at(12, 9, 0)},
+			want:  []time.Duration{time.Hour, 30 * time.Minute},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commits := make([]*Commit, len(tc.dates))
+			for i, d := range tc.dates {
+				commits[i] = &Commit{Date: d}
+			}
+			got := Estimate(commits, 2*time.Hour, 30*time.Minute)
+			for i, c := range commits {
+				if got[c] != tc.want[i] {
+					t.Errorf("commit %d: expected %s, got %s", i, tc.want[i], got[c])
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,12 @@
 package main
 
-import "time"
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+var jiraRE = regexp.MustCompile(`PD-\d+`)
 
 func startOfDay(t time.Time) time.Time {
 	year, month, day := t.Date()
@@ -11,3 +17,34 @@ func endOfDay(t time.Time) time.Time {
 	year, month, day := t.Date()
 	return time.Date(year, month, day+1, 0, 0, 0, 0, t.Location()).Add(-1)
 }
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// splitJiraID finds the jira key in a pull request title and returns it along
+// with the rest of the title after the key.
+func splitJiraID(title string) (string, string) {
+	loc := jiraRE.FindStringIndex(title)
+	if loc == nil {
+		return "", strings.TrimSpace(title)
+	}
+	jiraID := title[loc[0]:loc[1]]
+	// drop everything up to the last copy of the key
+	description := title[loc[1]:]
+	if i := strings.LastIndex(description, jiraID); i != -1 {
+		description = description[i+len(jiraID):]
+	}
+	description = strings.TrimPrefix(description, ":")
+	return jiraID, strings.TrimSpace(description)
+}
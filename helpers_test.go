@@ -0,0 +1,35 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSplitJiraID(t *testing.T) {
+	testCases := []struct {
+		pattern     string
+		title       string
+		jiraID      string
+		description string
+	}{
+		{`PD-\d+`, "PD-12: Fix login", "PD-12", "Fix login"},
+		{`PD-\d+`, "PD-12 Fix login", "PD-12", "Fix login"},
+		{`PD-\d+`, "Fix login", "", "Fix login"},
+		{`PD-\d+`, "Revert PD-12: Fix login", "PD-12", "Fix login"},
+		{`\[PD-\d+\]`, "[PD-12] Fix login", "[PD-12]", "Fix login"},
+		{`\(?[A-Z]+-\d+\)?`, "(PD-1) Fix *all* the things", "(PD-1)", "Fix *all* the things"},
+		{`[A-Z]+-\d+\+?`, "PD-1+: Follow up", "PD-1+", "Follow up"},
+	}
+	defer func(re *regexp.Regexp) {
+		jiraRE = re
+	}(jiraRE)
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			jiraRE = regexp.MustCompile(tc.pattern)
+			jiraID, description := splitJiraID(tc.title)
+			if jiraID != tc.jiraID || description != tc.description {
+				t.Errorf("expected %q %q, got %q %q", tc.jiraID, tc.description, jiraID, description)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/abibby/what-it-do/config"
+	"github.com/abibby/what-it-do/ezoauth"
+	"github.com/abibby/what-it-do/httpcache"
+)
+
+const defaultHTTPCacheTTL = 5 * time.Minute
+
+// httpCacheTTL is how long new api responses are cached. Reports set it from
+// the config, the other commands leave it at 0 so they always see current
+// data.
+var httpCacheTTL time.Duration
+
+// httpCacheFinal reports whether a response is cached forever, reports for
+// days that are over set it.
+var httpCacheFinal func(req *http.Request) bool
+
+// setupHTTPCache caches the responses of every api client. Responses are
+// keyed by the profile and token namespace as well as the url so accounts
+// don't share them.
+func setupHTTPCache(s *settings, offline bool) {
+	cache := httpcache.New(config.HTTPCacheDir())
+	identity := config.Profile() + "/" + s.TokenNamespace
+	ezoauth.Offline = offline
+	ezoauth.WrapTransport = func(service string, rt http.RoundTripper) http.RoundTripper {
+		return &httpcache.Transport{
+			Transport: rt,
+			Cache:     cache,
+			Identity:  identity + "/" + service,
+			TTL:       httpCacheTTL,
+			Final:     httpCacheFinal,
+			Offline:   offline,
+		}
+	}
+}
+
+// useReportCache caches responses for a report ending at end for ttl until
+// reset is called.
+func useReportCache(end time.Time, ttl time.Duration) (reset func()) {
+	httpCacheTTL = ttl
+	httpCacheFinal = reportCacheFinal(end)
+	return func() {
+		httpCacheTTL = 0
+		httpCacheFinal = nil
+	}
+}
+
+// reportCacheFinal returns nil until end has passed. After that it reports
+// whether a request is scoped to the day by including end in its query, like
+// calendar's timeMax or bitbucket's updated_on search. The activity of a day
+// that is over doesn't change, other requests like the signed in user can.
+func reportCacheFinal(end time.Time) func(req *http.Request) bool {
+	if !time.Now().After(end) {
+		return nil
+	}
+	stamps := []string{end.Format(time.RFC3339), end.UTC().Format(time.RFC3339)}
+	return func(req *http.Request) bool {
+		q, err := url.QueryUnescape(req.URL.RawQuery)
+		if err != nil {
+			return false
+		}
+		for _, s := range stamps {
+			if strings.Contains(q, s) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/abibby/what-it-do/ezoauth"
+)
+
+func TestReportCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	defer func(wrap func(string, http.RoundTripper) http.RoundTripper, offline bool) {
+		ezoauth.WrapTransport = wrap
+		ezoauth.Offline = offline
+	}(ezoauth.WrapTransport, ezoauth.Offline)
+
+	requests := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.String()]++
+		w.Write([]byte(r.URL.String()))
+	}))
+	defer srv.Close()
+	setupHTTPCache(&settings{}, false)
+
+	today := endOfDay(time.Now())
+	yesterday := endOfDay(today.AddDate(0, 0, -1))
+	search := func(end time.Time) string {
+		return "/calendar/events?" + url.Values{"timeMax": {end.Format(time.RFC3339)}}.Encode()
+	}
+	testCases := []struct {
+		name     string
+		report   time.Time
+		url      string
+		requests int
+	}{
+		{"yesterday's user", yesterday, "/myself", 1},
+		{"yesterday's events", yesterday, search(yesterday), 1},
+		{"today's user", today, "/myself", 2},
+		{"today's events", today, search(today), 1},
+		{"yesterday's events again", today, search(yesterday), 1},
+		{"today's events again", today, search(today), 2},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// a ttl of 1ns expires every response that isn't kept forever
+			reset := useReportCache(tc.report, time.Nanosecond)
+			defer reset()
+			client := &http.Client{Transport: ezoauth.WrapTransport("test", http.DefaultTransport)}
+			resp, err := client.Get(srv.URL + tc.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			b, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.url {
+				t.Errorf("expected body %q, got %q", tc.url, b)
+			}
+			if requests[tc.url] != tc.requests {
+				t.Errorf("expected %d requests, got %d", tc.requests, requests[tc.url])
+			}
+		})
+	}
+}
+
+func TestReportCacheFinal(t *testing.T) {
+	loc := time.FixedZone("EST", -5*60*60)
+	end := endOfDay(time.Date(2024, time.November, 12, 0, 0, 0, 0, loc))
+	testCases := []struct {
+		name string
+		url  string
+		want bool
+	}{
+		{"calendar", "https://www.googleapis.com/calendar/v3/calendars/primary/events?timeMax=2024-11-12T23%3A59%3A59-05%3A00&timeMin=2024-11-12T00%3A00%3A00-05%3A00", true},
+		{"graph", "https://graph.microsoft.com/v1.0/me/calendarView?endDateTime=2024-11-13T04%3A59%3A59Z&startDateTime=2024-11-12T05%3A00%3A00Z", true},
+		{"bitbucket", "https://api.bitbucket.org/2.0/pullrequests/me?q=updated_on+%3E+2024-11-12T00%3A00%3A00-05%3A00+AND+updated_on+%3C+2024-11-12T23%3A59%3A59-05%3A00", true},
+		{"another day", "https://www.googleapis.com/calendar/v3/calendars/primary/events?timeMax=2024-11-13T23%3A59%3A59-05%3A00", false},
+		{"no date", "https://example.atlassian.net/rest/api/3/myself", false},
+	}
+	final := reportCacheFinal(end)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := final(req); got != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	if reportCacheFinal(endOfDay(time.Now())) != nil {
+		t.Error("expected today's requests to expire")
+	}
+}
@@ -0,0 +1,149 @@
+// Package httpcache caches api responses on disk so reports for days that are
+// over don't fetch the same pages again.
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Cache is a directory of cached responses.
+type Cache struct {
+	dir string
+}
+
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Dir returns the directory the responses are saved in.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+type entry struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Identity   string      `json:"identity"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	StoredAt   time.Time   `json:"stored_at"`
+	// Expires is zero for responses that are kept forever.
+	Expires time.Time `json:"expires"`
+}
+
+func (e *entry) fresh(now time.Time) bool {
+	return e.Expires.IsZero() || now.Before(e.Expires)
+}
+
+func key(method, url, identity string) string {
+	h := sha256.New()
+	for _, s := range []string{method, url, identity} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *Cache) path(key string) string {
+	return path.Join(c.dir, key[:2], key+".json")
+}
+
+func (c *Cache) load(key string) (*entry, error) {
+	b, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, err
+	}
+	e := &entry{}
+	err = json.Unmarshal(b, e)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (c *Cache) save(key string, e *entry) error {
+	p := c.path(key)
+	err := os.MkdirAll(path.Dir(p), 0700)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	// write then rename so concurrent readers never see half a response
+	f, err := os.CreateTemp(path.Dir(p), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), p)
+}
+
+// Stats describes the responses in a cache.
+type Stats struct {
+	Entries int
+	Expired int
+	Size    int64
+	Newest  time.Time
+}
+
+// Stats reads every response in the cache.
+func (c *Cache) Stats() (*Stats, error) {
+	stats := &Stats{}
+	now := time.Now()
+	err := filepath.WalkDir(c.dir, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) && p == c.dir {
+			return fs.SkipAll
+		} else if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(p) != ".json" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		e, err := c.load(strings.TrimSuffix(d.Name(), ".json"))
+		if err != nil {
+			return err
+		}
+		stats.Entries++
+		stats.Size += info.Size()
+		if !e.fresh(now) {
+			stats.Expired++
+		}
+		if e.StoredAt.After(stats.Newest) {
+			stats.Newest = e.StoredAt
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// Clear deletes every response in the cache.
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.dir)
+}
@@ -0,0 +1,122 @@
+package httpcache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Forever is a TTL for responses that never change, like the activity of a
+// day that is over.
+const Forever time.Duration = math.MaxInt64
+
+// ErrNotCached is returned in offline mode for requests without a cached
+// response.
+var ErrNotCached = errors.New("response is not cached")
+
+// Transport serves GET requests from the cache and saves successful
+// responses. Other requests are sent as is.
+type Transport struct {
+	Transport http.RoundTripper
+	Cache     *Cache
+	// Identity is part of the cache key so different accounts don't share
+	// responses.
+	Identity string
+	// TTL is how long new responses are used for, Forever keeps them until
+	// the cache is cleared. Responses aren't cached when it is 0.
+	TTL time.Duration
+	// Final reports whether the response to a request can't change any more,
+	// like a search for a day that is over. Those responses are kept Forever.
+	Final func(req *http.Request) bool
+	// Offline serves cached responses even when they have expired and fails
+	// with ErrNotCached instead of sending requests.
+	Offline bool
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		if t.Offline {
+			return nil, fmt.Errorf("%w: %s %s", ErrNotCached, req.Method, req.URL)
+		}
+		return t.Transport.RoundTrip(req)
+	}
+	if t.TTL == 0 && !t.Offline {
+		return t.Transport.RoundTrip(req)
+	}
+
+	k := key(req.Method, req.URL.String(), t.Identity)
+	e, err := t.Cache.load(k)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("Failed to read cached response", "url", req.URL, "err", err)
+	}
+	if e != nil && (t.Offline || e.fresh(time.Now())) {
+		slog.Debug("Cached response", "url", req.URL, "stored_at", e.StoredAt)
+		return e.response(req), nil
+	}
+	if t.Offline {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotCached, req.Method, req.URL)
+	}
+
+	resp, err := t.Transport.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	now := time.Now()
+	e = &entry{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Identity:   t.Identity,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+		StoredAt:   now,
+	}
+	ttl := t.TTL
+	if t.Final != nil && t.Final(req) {
+		ttl = Forever
+	}
+	if ttl != Forever {
+		e.Expires = now.Add(ttl)
+	}
+	err = t.Cache.save(k, e)
+	if err != nil {
+		slog.Warn("Failed to cache response", "url", req.URL, "err", err)
+	}
+	return resp, nil
+}
+
+func (e *entry) response(req *http.Request) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Length", strconv.Itoa(len(e.Body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
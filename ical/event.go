@@ -0,0 +1,264 @@
+package ical
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+type Attendee struct {
+	Email string
+	Name  string
+	// PartStat is the participation status e.g. ACCEPTED, DECLINED,
+	// TENTATIVE or NEEDS-ACTION.
+	PartStat string
+	// CUType is the calendar user type e.g. INDIVIDUAL or ROOM.
+	CUType string
+}
+
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Status      string
+	// BusyStatus is Outlook's X-MICROSOFT-CDO-BUSYSTATUS e.g. FREE, BUSY,
+	// TENTATIVE or OOF.
+	BusyStatus string
+	Start      time.Time
+	End        time.Time
+	// AllDay is set when DTSTART is a DATE rather than a DATE-TIME.
+	AllDay    bool
+	Organizer *Attendee
+	Attendees []*Attendee
+
+	RRule        *RRule
+	ExDates      []time.Time
+	RecurrenceID time.Time
+}
+
+// ParseEvents returns the VEVENTs in an iCalendar stream. Floating times and
+// unknown time zones use loc.
+func ParseEvents(r io.Reader, loc *time.Location) ([]*Event, error) {
+	components, err := Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []*Event{}
+	for _, cal := range components {
+		for _, c := range cal.Components {
+			if c.Name != "VEVENT" {
+				continue
+			}
+			e, err := eventFromComponent(c, loc)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func eventFromComponent(c *Component, loc *time.Location) (*Event, error) {
+	e := &Event{}
+	if p := c.Get("UID"); p != nil {
+		e.UID = p.Value
+	}
+	if p := c.Get("SUMMARY"); p != nil {
+		e.Summary = unescapeText(p.Value)
+	}
+	if p := c.Get("DESCRIPTION"); p != nil {
+		e.Description = unescapeText(p.Value)
+	}
+	if p := c.Get("LOCATION"); p != nil {
+		e.Location = unescapeText(p.Value)
+	}
+	if p := c.Get("URL"); p != nil {
+		e.URL = p.Value
+	}
+	if p := c.Get("STATUS"); p != nil {
+		e.Status = strings.ToUpper(p.Value)
+	}
+	if p := c.Get("X-MICROSOFT-CDO-BUSYSTATUS"); p != nil {
+		e.BusyStatus = strings.ToUpper(p.Value)
+	}
+
+	dtstart := c.Get("DTSTART")
+	if dtstart == nil {
+		return nil, fmt.Errorf("event %s has no DTSTART", e.UID)
+	}
+	start, allDay, err := parseDateTime(dtstart, dtstart.Value, loc)
+	if err != nil {
+		return nil, fmt.Errorf("event %s DTSTART: %w", e.UID, err)
+	}
+	e.Start = start
+	e.AllDay = allDay
+
+	if dtend := c.Get("DTEND"); dtend != nil {
+		e.End, _, err = parseDateTime(dtend, dtend.Value, loc)
+		if err != nil {
+			return nil, fmt.Errorf("event %s DTEND: %w", e.UID, err)
+		}
+	} else if dur := c.Get("DURATION"); dur != nil {
+		d, err := parseDuration(dur.Value)
+		if err != nil {
+			return nil, fmt.Errorf("event %s DURATION: %w", e.UID, err)
+		}
+		e.End = e.Start.Add(d)
+	} else if allDay {
+		e.End = e.Start.AddDate(0, 0, 1)
+	} else {
+		e.End = e.Start
+	}
+
+	if p := c.Get("ORGANIZER"); p != nil {
+		e.Organizer = attendeeFromProperty(p)
+	}
+	for _, p := range c.GetAll("ATTENDEE") {
+		e.Attendees = append(e.Attendees, attendeeFromProperty(p))
+	}
+
+	if p := c.Get("RRULE"); p != nil {
+		e.RRule, err = ParseRRule(p.Value, loc)
+		if err != nil {
+			return nil, fmt.Errorf("event %s RRULE: %w", e.UID, err)
+		}
+	}
+	for _, p := range c.GetAll("EXDATE") {
+		for _, v := range strings.Split(p.Value, ",") {
+			t, _, err := parseDateTime(p, v, e.Start.Location())
+			if err != nil {
+				return nil, fmt.Errorf("event %s EXDATE: %w", e.UID, err)
+			}
+			e.ExDates = append(e.ExDates, t)
+		}
+	}
+	if p := c.Get("RECURRENCE-ID"); p != nil {
+		e.RecurrenceID, _, err = parseDateTime(p, p.Value, e.Start.Location())
+		if err != nil {
+			return nil, fmt.Errorf("event %s RECURRENCE-ID: %w", e.UID, err)
+		}
+	}
+	return e, nil
+}
+
+func attendeeFromProperty(p *Property) *Attendee {
+	email := p.Value
+	if len(email) > 7 && strings.EqualFold(email[:7], "mailto:") {
+		email = email[7:]
+	}
+	return &Attendee{
+		Email:    email,
+		Name:     p.Param("CN"),
+		PartStat: strings.ToUpper(p.Param("PARTSTAT")),
+		CUType:   strings.ToUpper(p.Param("CUTYPE")),
+	}
+}
+
+// parseDateTime parses a DATE or DATE-TIME value using the TZID param of p.
+// The returned bool is true for DATE values.
+func parseDateTime(p *Property, value string, loc *time.Location) (time.Time, bool, error) {
+	if p.Param("VALUE") == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	if tzid := p.Param("TZID"); tzid != "" {
+		loc = location(tzid, loc)
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// windowsZones maps the Windows time zone names used by Outlook exports to
+// IANA names.
+var windowsZones = map[string]string{
+	"Eastern Standard Time":        "America/New_York",
+	"Central Standard Time":        "America/Chicago",
+	"Mountain Standard Time":       "America/Denver",
+	"Pacific Standard Time":        "America/Los_Angeles",
+	"Atlantic Standard Time":       "America/Halifax",
+	"Newfoundland Standard Time":   "America/St_Johns",
+	"GMT Standard Time":            "Europe/London",
+	"W. Europe Standard Time":      "Europe/Berlin",
+	"Romance Standard Time":        "Europe/Paris",
+	"Central Europe Standard Time": "Europe/Budapest",
+	"India Standard Time":          "Asia/Kolkata",
+	"AUS Eastern Standard Time":    "Australia/Sydney",
+	"UTC":                          "UTC",
+}
+
+func location(tzid string, fallback *time.Location) *time.Location {
+	tzid = strings.TrimPrefix(tzid, "/")
+	if name, ok := windowsZones[tzid]; ok {
+		tzid = name
+	}
+	loc, err := time.LoadLocation(tzid)
+	if err != nil {
+		slog.Warn("unknown time zone in calendar, using default", "tzid", tzid, "default", fallback)
+		return fallback
+	}
+	return loc
+}
+
+// parseDuration parses an iCalendar DURATION like PT1H30M or P1D.
+func parseDuration(s string) (time.Duration, error) {
+	orig := s
+	sign := time.Duration(1)
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+		s = s[1:]
+	}
+	s = strings.TrimPrefix(s, "+")
+	if !strings.HasPrefix(s, "P") {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+	s = s[1:]
+
+	var d time.Duration
+	inTime := false
+	n := 0
+	hasNum := false
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			n = n*10 + int(r-'0')
+			hasNum = true
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		}
+		if !hasNum {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		switch {
+		case r == 'W':
+			d += time.Duration(n) * 7 * 24 * time.Hour
+		case r == 'D':
+			d += time.Duration(n) * 24 * time.Hour
+		case r == 'H' && inTime:
+			d += time.Duration(n) * time.Hour
+		case r == 'M' && inTime:
+			d += time.Duration(n) * time.Minute
+		case r == 'S' && inTime:
+			d += time.Duration(n) * time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		n = 0
+		hasNum = false
+	}
+	if hasNum {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+	return sign * d, nil
+}
@@ -0,0 +1,92 @@
+package ical
+
+import (
+	"slices"
+	"time"
+)
+
+// Expand returns the events and recurrence occurrences that overlap start and
+// end. Occurrences have RRule cleared, EXDATEs are skipped and occurrences
+// with a RECURRENCE-ID override are replaced by the override. Cancelled
+// events are dropped.
+func Expand(events []*Event, start, end time.Time) []*Event {
+	type occurrence struct {
+		uid string
+		at  int64
+	}
+	overrides := map[occurrence]*Event{}
+	for _, e := range events {
+		if !e.RecurrenceID.IsZero() {
+			overrides[occurrence{e.UID, e.RecurrenceID.Unix()}] = e
+		}
+	}
+
+	result := []*Event{}
+	used := map[*Event]bool{}
+	add := func(e *Event) {
+		if e.Status == "CANCELLED" || !overlaps(e, start, end) {
+			return
+		}
+		result = append(result, e)
+	}
+
+	for _, e := range events {
+		if !e.RecurrenceID.IsZero() {
+			continue
+		}
+		if e.RRule == nil {
+			add(e)
+			continue
+		}
+
+		duration := e.End.Sub(e.Start)
+		for _, at := range e.RRule.Occurrences(e.Start, end) {
+			if isExcluded(e, at) {
+				continue
+			}
+			if override, ok := overrides[occurrence{e.UID, at.Unix()}]; ok {
+				used[override] = true
+				add(override)
+				continue
+			}
+			o := *e
+			o.RRule = nil
+			o.ExDates = nil
+			o.Start = at
+			o.End = at.Add(duration)
+			add(&o)
+		}
+	}
+
+	// overrides can move an occurrence from outside the range into it
+	for _, override := range overrides {
+		if !used[override] {
+			add(override)
+		}
+	}
+
+	slices.SortStableFunc(result, func(a, b *Event) int {
+		return a.Start.Compare(b.Start)
+	})
+	return result
+}
+
+func overlaps(e *Event, start, end time.Time) bool {
+	if e.End.Equal(e.Start) {
+		return !e.Start.Before(start) && e.Start.Before(end)
+	}
+	return e.Start.Before(end) && e.End.After(start)
+}
+
+func isExcluded(e *Event, at time.Time) bool {
+	for _, ex := range e.ExDates {
+		if ex.Equal(at) {
+			return true
+		}
+		// EXDATE;VALUE=DATE excludes the whole day
+		if e.AllDay && ex.Year() == at.Year() && ex.YearDay() == at.YearDay() {
+			return true
+		}
+	}
+	return false
+}
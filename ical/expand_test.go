@@ -0,0 +1,120 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpand(t *testing.T) {
+	ics := calendar(
+		"BEGIN:VEVENT",
+		"UID:standup",
+		"SUMMARY:Standup",
+		"DTSTART;TZID=America/Toronto:20241105T093000",
+		"DTEND;TZID=America/Toronto:20241105T094500",
+		"RRULE:FREQ=WEEKLY;BYDAY=TU,TH",
+		"EXDATE;TZID=America/Toronto:20241114T093000",
+		"END:VEVENT",
+		// moved to 10:00
+		"BEGIN:VEVENT",
+		"UID:standup",
+		"SUMMARY:Standup (late)",
+		"RECURRENCE-ID;TZID=America/Toronto:20241119T093000",
+		"DTSTART;TZID=America/Toronto:20241119T100000",
+		"DTEND;TZID=America/Toronto:20241119T101500",
+		"END:VEVENT",
+		// moved from the week before into the range
+		"BEGIN:VEVENT",
+		"UID:standup",
+		"SUMMARY:Standup (moved)",
+		"RECURRENCE-ID;TZID=America/Toronto:20241107T093000",
+		"DTSTART;TZID=America/Toronto:20241111T093000",
+		"DTEND;TZID=America/Toronto:20241111T094500",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:planning",
+		"SUMMARY:Planning",
+		"STATUS:CANCELLED",
+		"DTSTART;TZID=America/Toronto:20241113T130000",
+		"DTEND;TZID=America/Toronto:20241113T140000",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:review",
+		"SUMMARY:Review",
+		"DTSTART:20241115T200000Z",
+		"DURATION:PT30M",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:before",
+		"SUMMARY:Last week",
+		"DTSTART:20241108T200000Z",
+		"DURATION:PT30M",
+		"END:VEVENT",
+	)
+	events, err := ParseEvents(strings.NewReader(ics), toronto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, time.November, 11, 0, 0, 0, 0, toronto)
+	end := time.Date(2024, time.November, 23, 0, 0, 0, 0, toronto)
+	at := func(d, h, m int) time.Time {
+		return time.Date(2024, time.November, d, h, m, 0, 0, toronto)
+	}
+	type occurrence struct {
+		summary    string
+		start, end time.Time
+	}
+	want := []occurrence{
+		{"Standup (moved)", at(11, 9, 30), at(11, 9, 45)},
+		{"Standup", at(12, 9, 30), at(12, 9, 45)},
+		{"Review", at(15, 15, 0), at(15, 15, 30)},
+		{"Standup (late)", at(19, 10, 0), at(19, 10, 15)},
+		{"Standup", at(21, 9, 30), at(21, 9, 45)},
+	}
+
+	got := Expand(events, start, end)
+	if len(got) != len(want) {
+		for _, e := range got {
+			t.Logf("%s %v", e.Summary, e.Start)
+		}
+		t.Fatalf("expected %d events, got %d", len(want), len(got))
+	}
+	for i, e := range got {
+		if e.Summary != want[i].summary || !e.Start.Equal(want[i].start) || !e.End.Equal(want[i].end) {
+			t.Errorf("event %d: expected %+v, got %s %v - %v", i, want[i], e.Summary, e.Start, e.End)
+		}
+		if e.RRule != nil || e.ExDates != nil {
+			t.Errorf("event %d: occurrences shouldn't recur", i)
+		}
+	}
+}
+
+func TestExpandAllDay(t *testing.T) {
+	ics := calendar(
+		"BEGIN:VEVENT",
+		"UID:gym",
+		"SUMMARY:Gym",
+		"DTSTART;VALUE=DATE:20241111",
+		"DTEND;VALUE=DATE:20241112",
+		"RRULE:FREQ=DAILY;COUNT=5",
+		"EXDATE;VALUE=DATE:20241113",
+		"END:VEVENT",
+	)
+	events, err := ParseEvents(strings.NewReader(ics), toronto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := Expand(events, time.Date(2024, time.November, 12, 0, 0, 0, 0, toronto), time.Date(2024, time.November, 15, 0, 0, 0, 0, toronto))
+	want := []int{12, 14}
+	if len(got) != len(want) {
+		t.Fatalf("expected days %v, got %d events", want, len(got))
+	}
+	for i, e := range got {
+		if !e.AllDay || e.Start.Day() != want[i] || !e.End.Equal(e.Start.AddDate(0, 0, 1)) {
+			t.Errorf("event %d: expected an all day event on the %d, got %v - %v", i, want[i], e.Start, e.End)
+		}
+	}
+}
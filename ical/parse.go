@@ -0,0 +1,173 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Property is a content line e.g. DTSTART;TZID=America/Toronto:20240102T090000
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+func (p *Property) Param(name string) string {
+	return p.Params[name]
+}
+
+// Component is a BEGIN/END block, only the properties of the component itself
+// are kept, nested components are in Components.
+type Component struct {
+	Name       string
+	Properties []*Property
+	Components []*Component
+}
+
+// Get returns the first property with the name or nil if there isn't one.
+func (c *Component) Get(name string) *Property {
+	for _, p := range c.Properties {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// GetAll returns every property with the name.
+func (c *Component) GetAll(name string) []*Property {
+	props := []*Property{}
+	for _, p := range c.Properties {
+		if p.Name == name {
+			props = append(props, p)
+		}
+	}
+	return props
+}
+
+// Parse reads an iCalendar stream and returns its top level components,
+// normally a single VCALENDAR.
+func Parse(r io.Reader) ([]*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &Component{}
+	stack := []*Component{root}
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		prop, err := parseProperty(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		current := stack[len(stack)-1]
+		switch prop.Name {
+		case "BEGIN":
+			c := &Component{Name: strings.ToUpper(prop.Value)}
+			current.Components = append(current.Components, c)
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 1 || current.Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", i+1, prop.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			current.Properties = append(current.Properties, prop)
+		}
+	}
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("missing END:%s", stack[len(stack)-1].Name)
+	}
+	return root.Components, nil
+}
+
+// unfold joins content lines that were split over multiple physical lines.
+func unfold(r io.Reader) ([]string, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parseProperty(line string) (*Property, error) {
+	inQuote := false
+	valueStart := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuote = !inQuote
+		} else if r == ':' && !inQuote {
+			valueStart = i
+			break
+		}
+	}
+	if valueStart == -1 {
+		return nil, fmt.Errorf("invalid content line %q", line)
+	}
+
+	prop := &Property{
+		Params: map[string]string{},
+		Value:  line[valueStart+1:],
+	}
+	parts := splitQuoted(line[:valueStart], ';')
+	prop.Name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		k, v, _ := strings.Cut(param, "=")
+		prop.Params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return prop, nil
+}
+
+func splitQuoted(s string, sep rune) []string {
+	parts := []string{}
+	inQuote := false
+	start := 0
+	for i, r := range s {
+		if r == '"' {
+			inQuote = !inQuote
+		} else if r == sep && !inQuote {
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unescapeText decodes a TEXT value.
+func unescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	b := strings.Builder{}
+	escaped := false
+	for _, r := range s {
+		if escaped {
+			switch r {
+			case 'n', 'N':
+				b.WriteRune('\n')
+			default:
+				b.WriteRune(r)
+			}
+			escaped = false
+			continue
+		}
+		if r == '\\' {
+			escaped = true
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
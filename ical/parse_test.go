@@ -0,0 +1,299 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var toronto = mustLoadLocation("America/Toronto")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// calendar wraps VEVENT lines in a VCALENDAR with CRLF line endings.
+func calendar(lines ...string) string {
+	lines = append([]string{"BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:-//Test//EN"}, lines...)
+	lines = append(lines, "END:VCALENDAR", "")
+	return strings.Join(lines, "\r\n")
+}
+
+func parseEvent(t *testing.T, lines ...string) *Event {
+	t.Helper()
+	events, err := ParseEvents(strings.NewReader(calendar(lines...)), toronto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	return events[0]
+}
+
+func TestParseEventTimes(t *testing.T) {
+	testCases := []struct {
+		name   string
+		lines  []string
+		start  time.Time
+		end    time.Time
+		allDay bool
+	}{
+		{
+			name:  "tzid",
+			lines: []string{"DTSTART;TZID=America/Vancouver:20241112T090000", "DTEND;TZID=America/Vancouver:20241112T100000"},
+			start: time.Date(2024, time.November, 12, 9, 0, 0, 0, mustLoadLocation("America/Vancouver")),
+			end:   time.Date(2024, time.November, 12, 10, 0, 0, 0, mustLoadLocation("America/Vancouver")),
+		},
+		{
+			name:  "quoted windows tzid",
+			lines: []string{`DTSTART;TZID="Pacific Standard Time":20241112T090000`, `DTEND;TZID="Pacific Standard Time":20241112T093000`},
+			start: time.Date(2024, time.November, 12, 9, 0, 0, 0, mustLoadLocation("America/Los_Angeles")),
+			end:   time.Date(2024, time.November, 12, 9, 30, 0, 0, mustLoadLocation("America/Los_Angeles")),
+		},
+		{
+			name:  "unknown tzid",
+			lines: []string{"DTSTART;TZID=Custom Zone 1:20241112T090000", "DTEND;TZID=Custom Zone 1:20241112T093000"},
+			start: time.Date(2024, time.November, 12, 9, 0, 0, 0, toronto),
+			end:   time.Date(2024, time.November, 12, 9, 30, 0, 0, toronto),
+		},
+		{
+			name:  "utc",
+			lines: []string{"DTSTART:20241112T140000Z", "DTEND:20241112T150000Z"},
+			start: time.Date(2024, time.November, 12, 14, 0, 0, 0, time.UTC),
+			end:   time.Date(2024, time.November, 12, 15, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "floating",
+			lines: []string{"DTSTART:20241112T090000", "DTEND:20241112T100000"},
+			start: time.Date(2024, time.November, 12, 9, 0, 0, 0, toronto),
+			end:   time.Date(2024, time.November, 12, 10, 0, 0, 0, toronto),
+		},
+		{
+			name:  "duration",
+			lines: []string{"DTSTART:20241112T140000Z", "DURATION:PT1H30M"},
+			start: time.Date(2024, time.November, 12, 14, 0, 0, 0, time.UTC),
+			end:   time.Date(2024, time.November, 12, 15, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "no end",
+			lines: []string{"DTSTART:20241112T140000Z"},
+			start: time.Date(2024, time.November, 12, 14, 0, 0, 0, time.UTC),
+			end:   time.Date(2024, time.November, 12, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "all day",
+			lines:  []string{"DTSTART;VALUE=DATE:20241112", "DTEND;VALUE=DATE:20241114"},
+			start:  time.Date(2024, time.November, 12, 0, 0, 0, 0, toronto),
+			end:    time.Date(2024, time.November, 14, 0, 0, 0, 0, toronto),
+			allDay: true,
+		},
+		{
+			name:   "all day without end",
+			lines:  []string{"DTSTART;VALUE=DATE:20241112"},
+			start:  time.Date(2024, time.November, 12, 0, 0, 0, 0, toronto),
+			end:    time.Date(2024, time.November, 13, 0, 0, 0, 0, toronto),
+			allDay: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lines := append([]string{"BEGIN:VEVENT", "UID:1"}, tc.lines...)
+			e := parseEvent(t, append(lines, "END:VEVENT")...)
+			if !e.Start.Equal(tc.start) || e.Start.Location().String() != tc.start.Location().String() {
+				t.Errorf("expected start %v, got %v", tc.start, e.Start)
+			}
+			if !e.End.Equal(tc.end) {
+				t.Errorf("expected end %v, got %v", tc.end, e.End)
+			}
+			if e.AllDay != tc.allDay {
+				t.Errorf("expected all day %v, got %v", tc.allDay, e.AllDay)
+			}
+		})
+	}
+}
+
+func TestParseEventProperties(t *testing.T) {
+	e := parseEvent(t,
+		"BEGIN:VEVENT",
+		"UID:abc@example.com",
+		"SUMMARY:PD-12: Weekly plan",
+		" ning sync",
+		"DESCRIPTION:Agenda:\\n1. Status\\, risks\\; blockers",
+		"\tJoin at https://meet.example.com/abc",
+		"LOCATION:Room 4",
+		"STATUS:tentative",
+		"X-MICROSOFT-CDO-BUSYSTATUS:OOF",
+		"DTSTART;TZID=America/Toronto:20241112T090000",
+		"DTEND;TZID=America/Toronto:20241112T100000",
+		`ORGANIZER;CN="Doe: Jane":mailto:jane@example.com`,
+		"ATTENDEE;CN=Me;PARTSTAT=declined:MAILTO:me@example.com",
+		"ATTENDEE;CUTYPE=ROOM;PARTSTAT=ACCEPTED:mailto:room-4@example.com",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		"TRIGGER:-PT10M",
+		"END:VALARM",
+		"END:VEVENT",
+	)
+
+	if e.UID != "abc@example.com" {
+		t.Errorf("unexpected uid %q", e.UID)
+	}
+	if e.Summary != "PD-12: Weekly planning sync" {
+		t.Errorf("unexpected summary %q", e.Summary)
+	}
+	if want := "Agenda:\n1. Status, risks; blockersJoin at https://meet.example.com/abc"; e.Description != want {
+		t.Errorf("expected description %q, got %q", want, e.Description)
+	}
+	if e.Location != "Room 4" || e.Status != "TENTATIVE" || e.BusyStatus != "OOF" {
+		t.Errorf("unexpected location %q, status %q or busy status %q", e.Location, e.Status, e.BusyStatus)
+	}
+	if e.Organizer == nil || e.Organizer.Email != "jane@example.com" || e.Organizer.Name != "Doe: Jane" {
+		t.Errorf("unexpected organizer %+v", e.Organizer)
+	}
+	want := []Attendee{
+		{Email: "me@example.com", Name: "Me", PartStat: "DECLINED"},
+		{Email: "room-4@example.com", PartStat: "ACCEPTED", CUType: "ROOM"},
+	}
+	if len(e.Attendees) != len(want) {
+		t.Fatalf("expected %d attendees, got %d", len(want), len(e.Attendees))
+	}
+	for i, a := range e.Attendees {
+		if *a != want[i] {
+			t.Errorf("attendee %d: expected %+v, got %+v", i, want[i], *a)
+		}
+	}
+}
+
+func TestParseEventRecurrence(t *testing.T) {
+	e := parseEvent(t,
+		"BEGIN:VEVENT",
+		"UID:standup",
+		"DTSTART;TZID=America/Toronto:20241112T093000",
+		"DTEND;TZID=America/Toronto:20241112T094500",
+		"RRULE:FREQ=WEEKLY;BYDAY=TU,TH;UNTIL=20241130T000000Z",
+		"EXDATE;TZID=America/Toronto:20241114T093000,20241119T093000",
+		"EXDATE;TZID=America/Toronto:20241121T093000",
+		"END:VEVENT",
+	)
+	if e.RRule == nil || e.RRule.Freq != "WEEKLY" || len(e.RRule.ByDay) != 2 {
+		t.Fatalf("unexpected rrule %+v", e.RRule)
+	}
+	want := []time.Time{
+		time.Date(2024, time.November, 14, 9, 30, 0, 0, toronto),
+		time.Date(2024, time.November, 19, 9, 30, 0, 0, toronto),
+		time.Date(2024, time.November, 21, 9, 30, 0, 0, toronto),
+	}
+	if len(e.ExDates) != len(want) {
+		t.Fatalf("expected %d exdates, got %v", len(want), e.ExDates)
+	}
+	for i, ex := range e.ExDates {
+		if !ex.Equal(want[i]) {
+			t.Errorf("exdate %d: expected %v, got %v", i, want[i], ex)
+		}
+	}
+
+	override := parseEvent(t,
+		"BEGIN:VEVENT",
+		"UID:standup",
+		"RECURRENCE-ID;TZID=America/Toronto:20241119T093000",
+		"DTSTART;TZID=America/Toronto:20241119T100000",
+		"DTEND;TZID=America/Toronto:20241119T101500",
+		"END:VEVENT",
+	)
+	if want := time.Date(2024, time.November, 19, 9, 30, 0, 0, toronto); !override.RecurrenceID.Equal(want) {
+		t.Errorf("expected recurrence id %v, got %v", want, override.RecurrenceID)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		ics  string
+		err  string
+	}{
+		{
+			name: "no dtstart",
+			ics:  calendar("BEGIN:VEVENT", "UID:1", "END:VEVENT"),
+			err:  "event 1 has no DTSTART",
+		},
+		{
+			name: "invalid dtstart",
+			ics:  calendar("BEGIN:VEVENT", "UID:1", "DTSTART:tomorrow", "END:VEVENT"),
+			err:  "event 1 DTSTART",
+		},
+		{
+			name: "invalid rrule",
+			ics:  calendar("BEGIN:VEVENT", "UID:1", "DTSTART:20241112T090000Z", "RRULE:FREQ=HOURLY", "END:VEVENT"),
+			err:  `event 1 RRULE: unsupported FREQ "HOURLY"`,
+		},
+		{
+			name: "unexpected end",
+			ics:  calendar("BEGIN:VEVENT", "UID:1", "END:VTODO"),
+			err:  "line 6: unexpected END:VTODO",
+		},
+		{
+			name: "missing end",
+			ics:  "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n",
+			err:  "missing END:VEVENT",
+		},
+		{
+			name: "invalid content line",
+			ics:  calendar("BEGIN:VEVENT", "no colon here", "END:VEVENT"),
+			err:  "line 5: invalid content line",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseEvents(strings.NewReader(tc.ics), toronto)
+			if err == nil {
+				t.Fatalf("expected an error containing %q", tc.err)
+			}
+			if !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected an error containing %q, got %q", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  time.Duration
+		err   bool
+	}{
+		{value: "PT1H30M", want: 90 * time.Minute},
+		{value: "PT45S", want: 45 * time.Second},
+		{value: "P1D", want: 24 * time.Hour},
+		{value: "P1DT2H", want: 26 * time.Hour},
+		{value: "P2W", want: 14 * 24 * time.Hour},
+		{value: "-PT15M", want: -15 * time.Minute},
+		{value: "+PT15M", want: 15 * time.Minute},
+		{value: "PT1H1", err: true},
+		{value: "1H", err: true},
+		{value: "P1H", err: true},
+		{value: "PTH", err: true},
+		{value: "P1X", err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := parseDuration(tc.value)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected an error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package ical
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type WeekdayNum struct {
+	// N is the ordinal for MONTHLY and YEARLY rules e.g. 2 in 2TU or -1 in
+	// -1FR, 0 means every matching weekday.
+	N       int
+	Weekday time.Weekday
+}
+
+// RRule is a recurrence rule. FREQ DAILY, WEEKLY, MONTHLY and YEARLY are
+// supported along with INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY and BYMONTH.
+type RRule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+func ParseRRule(value string, loc *time.Location) (*RRule, error) {
+	r := &RRule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		k, v, _ := strings.Cut(part, "=")
+		var err error
+		switch strings.ToUpper(k) {
+		case "FREQ":
+			r.Freq = strings.ToUpper(v)
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(v)
+		case "COUNT":
+			r.Count, err = strconv.Atoi(v)
+		case "UNTIL":
+			var allDay bool
+			r.Until, allDay, err = parseDateTime(&Property{}, v, loc)
+			if allDay {
+				r.Until = r.Until.AddDate(0, 0, 1).Add(-1)
+			}
+		case "BYDAY":
+			for _, d := range strings.Split(v, ",") {
+				if len(d) < 2 {
+					return nil, fmt.Errorf("invalid BYDAY %q", d)
+				}
+				wd, ok := weekdays[strings.ToUpper(d[len(d)-2:])]
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY %q", d)
+				}
+				n := 0
+				if len(d) > 2 {
+					n, err = strconv.Atoi(d[:len(d)-2])
+					if err != nil {
+						return nil, fmt.Errorf("invalid BYDAY %q", d)
+					}
+				}
+				r.ByDay = append(r.ByDay, WeekdayNum{N: n, Weekday: wd})
+			}
+		case "BYMONTHDAY":
+			for _, d := range strings.Split(v, ",") {
+				n, err := strconv.Atoi(d)
+				if err != nil {
+					return nil, fmt.Errorf("invalid BYMONTHDAY %q", d)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		case "BYMONTH":
+			for _, m := range strings.Split(v, ",") {
+				n, err := strconv.Atoi(m)
+				if err != nil || n < 1 || n > 12 {
+					return nil, fmt.Errorf("invalid BYMONTH %q", m)
+				}
+				r.ByMonth = append(r.ByMonth, time.Month(n))
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", k, err)
+		}
+	}
+
+	switch r.Freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return nil, fmt.Errorf("unsupported FREQ %q", r.Freq)
+	}
+	if r.Interval < 1 {
+		r.Interval = 1
+	}
+	return r, nil
+}
+
+// maxPeriods stops runaway expansion of rules that never match.
+const maxPeriods = 10000
+
+// Occurrences returns the start of every occurrence from dtstart up to end.
+// Occurrences before the range still count towards COUNT so callers should
+// filter the result.
+func (r *RRule) Occurrences(dtstart, end time.Time) []time.Time {
+	result := []time.Time{}
+	for period := 0; period < maxPeriods; period++ {
+		if r.periodStart(dtstart, period).After(end) {
+			break
+		}
+		for _, c := range r.candidates(dtstart, period) {
+			if c.Before(dtstart) {
+				continue
+			}
+			if !r.Until.IsZero() && c.After(r.Until) {
+				return result
+			}
+			if !c.Before(end) {
+				return result
+			}
+			result = append(result, c)
+			if r.Count > 0 && len(result) >= r.Count {
+				return result
+			}
+		}
+	}
+	return result
+}
+
+func (r *RRule) periodStart(dtstart time.Time, period int) time.Time {
+	y, m, d := dtstart.Date()
+	n := period * r.Interval
+	switch r.Freq {
+	case "DAILY":
+		return time.Date(y, m, d+n, 0, 0, 0, 0, dtstart.Location())
+	case "WEEKLY":
+		return time.Date(y, m, d+7*n-daysSinceMonday(dtstart.Weekday()), 0, 0, 0, 0, dtstart.Location())
+	case "MONTHLY":
+		return time.Date(y, m+time.Month(n), 1, 0, 0, 0, 0, dtstart.Location())
+	default:
+		return time.Date(y+n, 1, 1, 0, 0, 0, 0, dtstart.Location())
+	}
+}
+
+// candidates returns the sorted occurrence times in a period before COUNT and
+// UNTIL are applied.
+func (r *RRule) candidates(dtstart time.Time, period int) []time.Time {
+	loc := dtstart.Location()
+	hour, min, sec := dtstart.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, min, sec, 0, loc)
+	}
+	ps := r.periodStart(dtstart, period)
+	y, m, _ := ps.Date()
+
+	result := []time.Time{}
+	switch r.Freq {
+	case "DAILY":
+		day := at(ps.Date())
+		if r.matchesMonth(day.Month()) && r.matchesWeekday(day.Weekday()) && r.matchesMonthDay(day) {
+			result = append(result, day)
+		}
+	case "WEEKLY":
+		days := []time.Weekday{dtstart.Weekday()}
+		if len(r.ByDay) > 0 {
+			days = days[:0]
+			for _, wd := range r.ByDay {
+				days = append(days, wd.Weekday)
+			}
+		}
+		for _, wd := range days {
+			day := at(y, m, ps.Day()+daysSinceMonday(wd))
+			if r.matchesMonth(day.Month()) {
+				result = append(result, day)
+			}
+		}
+	case "MONTHLY":
+		if r.matchesMonth(m) {
+			for _, d := range r.monthDays(y, m, dtstart.Day()) {
+				result = append(result, at(y, m, d))
+			}
+		}
+	case "YEARLY":
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{dtstart.Month()}
+		}
+		for _, month := range months {
+			for _, d := range r.monthDays(y, month, dtstart.Day()) {
+				result = append(result, at(y, month, d))
+			}
+		}
+	}
+
+	slices.SortFunc(result, func(a, b time.Time) int {
+		return a.Compare(b)
+	})
+	return slices.CompactFunc(result, func(a, b time.Time) bool {
+		return a.Equal(b)
+	})
+}
+
+// monthDays returns the days of the month selected by BYMONTHDAY and BYDAY,
+// or defaultDay if neither is set.
+func (r *RRule) monthDays(y int, m time.Month, defaultDay int) []int {
+	daysIn := time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	days := []int{}
+	if len(r.ByMonthDay) > 0 {
+		for _, md := range r.ByMonthDay {
+			d := md
+			if md < 0 {
+				d = daysIn + md + 1
+			}
+			if d < 1 || d > daysIn {
+				continue
+			}
+			if len(r.ByDay) > 0 && !r.matchesWeekday(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Weekday()) {
+				continue
+			}
+			days = append(days, d)
+		}
+		return days
+	}
+
+	if len(r.ByDay) > 0 {
+		for _, wd := range r.ByDay {
+			matching := []int{}
+			for d := 1; d <= daysIn; d++ {
+				if time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Weekday() == wd.Weekday {
+					matching = append(matching, d)
+				}
+			}
+			switch {
+			case wd.N == 0:
+				days = append(days, matching...)
+			case wd.N > 0 && wd.N <= len(matching):
+				days = append(days, matching[wd.N-1])
+			case wd.N < 0 && -wd.N <= len(matching):
+				days = append(days, matching[len(matching)+wd.N])
+			}
+		}
+		return days
+	}
+
+	if defaultDay <= daysIn {
+		days = append(days, defaultDay)
+	}
+	return days
+}
+
+func (r *RRule) matchesMonth(m time.Month) bool {
+	return len(r.ByMonth) == 0 || slices.Contains(r.ByMonth, m)
+}
+
+func (r *RRule) matchesWeekday(wd time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, d := range r.ByDay {
+		if d.Weekday == wd {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RRule) matchesMonthDay(t time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	daysIn := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, md := range r.ByMonthDay {
+		if md == t.Day() || (md < 0 && daysIn+md+1 == t.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func daysSinceMonday(wd time.Weekday) int {
+	return (int(wd) + 6) % 7
+}
@@ -0,0 +1,178 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOccurrences(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 9, 30, 0, 0, toronto)
+	}
+	testCases := []struct {
+		name    string
+		rrule   string
+		dtstart time.Time
+		end     time.Time
+		want    []time.Time
+	}{
+		{
+			name:    "daily count",
+			rrule:   "FREQ=DAILY;COUNT=3",
+			dtstart: day(2024, 11, 12),
+			end:     day(2025, 1, 1),
+			want:    []time.Time{day(2024, 11, 12), day(2024, 11, 13), day(2024, 11, 14)},
+		},
+		{
+			name:    "daily until end",
+			rrule:   "FREQ=DAILY",
+			dtstart: day(2024, 11, 12),
+			end:     time.Date(2024, 11, 15, 0, 0, 0, 0, toronto),
+			want:    []time.Time{day(2024, 11, 12), day(2024, 11, 13), day(2024, 11, 14)},
+		},
+		{
+			name:    "interval and utc until on an occurrence",
+			rrule:   "FREQ=DAILY;INTERVAL=2;UNTIL=20241118T143000Z",
+			dtstart: day(2024, 11, 12),
+			end:     day(2025, 1, 1),
+			want:    []time.Time{day(2024, 11, 12), day(2024, 11, 14), day(2024, 11, 16), day(2024, 11, 18)},
+		},
+		{
+			name:    "date until includes the day",
+			rrule:   "FREQ=DAILY;UNTIL=20241114",
+			dtstart: day(2024, 11, 12),
+			end:     day(2025, 1, 1),
+			want:    []time.Time{day(2024, 11, 12), day(2024, 11, 13), day(2024, 11, 14)},
+		},
+		{
+			name:    "across the end of dst",
+			rrule:   "FREQ=DAILY;COUNT=3",
+			dtstart: day(2024, 11, 2),
+			end:     day(2025, 1, 1),
+			want:    []time.Time{day(2024, 11, 2), day(2024, 11, 3), day(2024, 11, 4)},
+		},
+		{
+			name:    "daily by day",
+			rrule:   "FREQ=DAILY;BYDAY=MO,FR;COUNT=3",
+			dtstart: day(2024, 11, 12),
+			end:     day(2025, 1, 1),
+			want:    []time.Time{day(2024, 11, 15), day(2024, 11, 18), day(2024, 11, 22)},
+		},
+		{
+			name:    "weekly",
+			rrule:   "FREQ=WEEKLY;COUNT=2",
+			dtstart: day(2024, 11, 12),
+			end:     day(2025, 1, 1),
+			want:    []time.Time{day(2024, 11, 12), day(2024, 11, 19)},
+		},
+		{
+			name:    "weekly by day skips days before dtstart",
+			rrule:   "FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=4",
+			dtstart: day(2024, 11, 13),
+			end:     day(2025, 1, 1),
+			want:    []time.Time{day(2024, 11, 13), day(2024, 11, 15), day(2024, 11, 18), day(2024, 11, 20)},
+		},
+		{
+			name:    "every other week",
+			rrule:   "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU",
+			dtstart: day(2024, 11, 12),
+			end:     time.Date(2024, 12, 1, 0, 0, 0, 0, toronto),
+			want:    []time.Time{day(2024, 11, 12), day(2024, 11, 26)},
+		},
+		{
+			name:    "monthly second tuesday",
+			rrule:   "FREQ=MONTHLY;BYDAY=2TU;COUNT=3",
+			dtstart: day(2024, 11, 12),
+			end:     day(2026, 1, 1),
+			want:    []time.Time{day(2024, 11, 12), day(2024, 12, 10), day(2025, 1, 14)},
+		},
+		{
+			name:    "monthly last friday",
+			rrule:   "FREQ=MONTHLY;BYDAY=-1FR;COUNT=2",
+			dtstart: day(2024, 11, 29),
+			end:     day(2026, 1, 1),
+			want:    []time.Time{day(2024, 11, 29), day(2024, 12, 27)},
+		},
+		{
+			name:    "monthly on the 31st skips short months",
+			rrule:   "FREQ=MONTHLY;COUNT=3",
+			dtstart: day(2025, 1, 31),
+			end:     day(2026, 1, 1),
+			want:    []time.Time{day(2025, 1, 31), day(2025, 3, 31), day(2025, 5, 31)},
+		},
+		{
+			name:    "monthly last day",
+			rrule:   "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3",
+			dtstart: day(2024, 11, 30),
+			end:     day(2026, 1, 1),
+			want:    []time.Time{day(2024, 11, 30), day(2024, 12, 31), day(2025, 1, 31)},
+		},
+		{
+			name:    "monthly day and weekday",
+			rrule:   "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13;COUNT=2",
+			dtstart: day(2024, 9, 13),
+			end:     day(2026, 1, 1),
+			want:    []time.Time{day(2024, 9, 13), day(2024, 12, 13)},
+		},
+		{
+			name:    "yearly leap day",
+			rrule:   "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29;COUNT=2",
+			dtstart: day(2024, 2, 29),
+			end:     day(2030, 1, 1),
+			want:    []time.Time{day(2024, 2, 29), day(2028, 2, 29)},
+		},
+		{
+			name:    "yearly first monday of september",
+			rrule:   "FREQ=YEARLY;BYMONTH=9;BYDAY=1MO;COUNT=2",
+			dtstart: day(2024, 9, 2),
+			end:     day(2030, 1, 1),
+			want:    []time.Time{day(2024, 9, 2), day(2025, 9, 1)},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := ParseRRule(tc.rrule, toronto)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := r.Occurrences(tc.dtstart, tc.end)
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+			for i := range got {
+				if !got[i].Equal(tc.want[i]) {
+					t.Errorf("occurrence %d: expected %v, got %v", i, tc.want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseRRuleErrors(t *testing.T) {
+	testCases := []struct {
+		rrule string
+		err   string
+	}{
+		{"FREQ=HOURLY", `unsupported FREQ "HOURLY"`},
+		{"COUNT=3", `unsupported FREQ ""`},
+		{"FREQ=DAILY;COUNT=three", "invalid COUNT"},
+		{"FREQ=DAILY;UNTIL=soon", "invalid UNTIL"},
+		{"FREQ=WEEKLY;BYDAY=XX", `invalid BYDAY "XX"`},
+		{"FREQ=WEEKLY;BYDAY=1", `invalid BYDAY "1"`},
+		{"FREQ=MONTHLY;BYDAY=AFR", `invalid BYDAY "AFR"`},
+		{"FREQ=MONTHLY;BYMONTHDAY=first", `invalid BYMONTHDAY "first"`},
+		{"FREQ=YEARLY;BYMONTH=13", `invalid BYMONTH "13"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.rrule, func(t *testing.T) {
+			_, err := ParseRRule(tc.rrule, toronto)
+			if err == nil {
+				t.Fatalf("expected an error containing %q", tc.err)
+			}
+			if !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected an error containing %q, got %q", tc.err, err)
+			}
+		})
+	}
+}
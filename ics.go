@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abibby/what-it-do/caldav"
+	"github.com/abibby/what-it-do/ezoauth"
+	"github.com/abibby/what-it-do/ical"
+	"google.golang.org/api/calendar/v3"
+)
+
+type icsSource struct {
+	// URL is a path to a .ics file or an http(s) or webcal URL.
+	URL     string `json:"url"`
+	Project string `json:"project"`
+}
+
+type calDAVSource struct {
+	// URL is the calendar collection e.g.
+	// https://cloud.example.com/remote.php/dav/calendars/me/personal/
+	URL      string `json:"url"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Project  string `json:"project"`
+}
+
+func listICSEvents(cfg *calendarConfig, source *icsSource, start, end time.Time) ([]*calendar.Event, error) {
+	r, err := openICS(source.URL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read calendar %s: %w", source.URL, err)
+	}
+	defer r.Close()
+
+	events, err := ical.ParseEvents(r, start.Location())
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse calendar %s: %w", source.URL, err)
+	}
+	return icalToGoogleEvents(cfg, ical.Expand(events, start, end)), nil
+}
+
+func listCalDAVEvents(cfg *calendarConfig, source *calDAVSource, start, end time.Time) ([]*calendar.Event, error) {
+	client := caldav.NewClient(&http.Client{
+		Transport: ezoauth.NewLogRoundTripper("caldav", http.DefaultTransport),
+	}, source.URL)
+	if source.Username != "" {
+		client.SetBasicAuth(source.Username, source.Password)
+	}
+	events, err := client.Events(start, end, start.Location())
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve events from calendar %s: %w", source.URL, err)
+	}
+	return icalToGoogleEvents(cfg, ical.Expand(events, start, end)), nil
+}
+
+func openICS(u string) (io.ReadCloser, error) {
+	if rest, ok := strings.CutPrefix(u, "webcal://"); ok {
+		u = "https://" + rest
+	}
+	if strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") {
+		client := &http.Client{
+			Transport: ezoauth.NewLogRoundTripper("ics", http.DefaultTransport),
+		}
+		resp, err := client.Get(u)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != 200 {
+			resp.Body.Close()
+			return nil, fmt.Errorf("fetch error: %s", resp.Status)
+		}
+		return resp.Body, nil
+	}
+
+	if rest, ok := strings.CutPrefix(u, "~"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		u = filepath.Join(home, rest)
+	}
+	return os.Open(u)
+}
+
+var partStatResponses = map[string]string{
+	"ACCEPTED":     "accepted",
+	"DECLINED":     "declined",
+	"TENTATIVE":    "tentative",
+	"NEEDS-ACTION": "needsAction",
+}
+
+// icalToGoogleEvents converts events to Google's event type so they can be
+// categorised the same way as Google calendar events.
+func icalToGoogleEvents(cfg *calendarConfig, events []*ical.Event) []*calendar.Event {
+	isSelf := func(email string) bool {
+		return slices.ContainsFunc(cfg.Emails, func(e string) bool {
+			return strings.EqualFold(e, email)
+		})
+	}
+
+	items := make([]*calendar.Event, 0, len(events))
+	for _, e := range events {
+		item := &calendar.Event{
+			ICalUID:     e.UID,
+			Summary:     e.Summary,
+			Description: e.Description,
+			Location:    e.Location,
+			HtmlLink:    e.URL,
+			Status:      strings.ToLower(e.Status),
+			EventType:   "default",
+			Start:       &calendar.EventDateTime{},
+			End:         &calendar.EventDateTime{},
+		}
+		if e.BusyStatus == "OOF" {
+			item.EventType = "outOfOffice"
+		}
+		if e.AllDay {
+			item.Start.Date = e.Start.Format(time.DateOnly)
+			item.End.Date = e.End.Format(time.DateOnly)
+		} else {
+			item.Start.DateTime = e.Start.Format(time.RFC3339)
+			item.End.DateTime = e.End.Format(time.RFC3339)
+		}
+		if e.Organizer != nil {
+			item.Organizer = &calendar.EventOrganizer{
+				Email:       e.Organizer.Email,
+				DisplayName: e.Organizer.Name,
+				Self:        isSelf(e.Organizer.Email),
+			}
+		}
+		for _, a := range e.Attendees {
+			status, ok := partStatResponses[a.PartStat]
+			if !ok {
+				status = "needsAction"
+			}
+			item.Attendees = append(item.Attendees, &calendar.EventAttendee{
+				Email:          a.Email,
+				DisplayName:    a.Name,
+				ResponseStatus: status,
+				Self:           isSelf(a.Email),
+				Resource:       a.CUType == "ROOM" || a.CUType == "RESOURCE",
+			})
+		}
+		items = append(items, item)
+	}
+	return items
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
+	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,30 +29,65 @@ type JiraOAuth struct {
 	URL string
 }
 
-func getJiraClient() (*jira.Client, error) {
-	ctx := context.Background()
-	config, err := ezoauth.ReadConfigJSON(config.Dir("atlassian_creds.json"))
+// defaultJQL finds the issues worked on in the current sprint. {user} is
+// replaced with the current user's display name.
+const defaultJQL = `project = PD AND (assignee = currentUser() OR issuekey in updatedBy("{user}")) AND sprint in openSprints() ORDER BY created DESC`
+
+// jiraConfig is the optional jira section of the config.
+type jiraConfig struct {
+	// JQL selects the issues to check for activity, {user} is replaced with
+	// the current user's display name.
+	JQL string `json:"jql"`
+}
+
+func readJiraConfig() (*jiraConfig, error) {
+	cfg := &jiraConfig{}
+	err := config.Section("jira", cfg)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if cfg.JQL == "" {
+		cfg.JQL = defaultJQL
+	}
+	return cfg, nil
+}
+
+func jiraOAuthConfig() (*ezoauth.Config, error) {
+	oauthConfig, err := ezoauth.ReadConfigJSON(config.Path("atlassian_creds.json"))
 	if err != nil {
 		return nil, err
 	}
-	config.Endpoint = oauth2.Endpoint{
+	oauthConfig.Endpoint = oauth2.Endpoint{
 		AuthURL:  "https://auth.atlassian.com/authorize",
 		TokenURL: "https://auth.atlassian.com/oauth/token",
 	}
 
-	ezconfig := &ezoauth.Config{
+	return &ezoauth.Config{
 		Name:        "jira",
-		OAuthConfig: config,
+		OAuthConfig: oauthConfig,
 		AuthCodeURLOpts: []oauth2.AuthCodeOption{
 			oauth2.SetAuthURLParam("audience", "api.atlassian.com"),
 			oauth2.ApprovalForce,
 		},
+		// Atlassian 3LO doesn't support PKCE
+		DisablePKCE: true,
+	}, nil
+}
+
+func getJiraClient() (*jira.Client, error) {
+	ctx := context.Background()
+	ezconfig, err := jiraOAuthConfig()
+	if err != nil {
+		return nil, err
 	}
 	client, err := ezconfig.Client(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("could not start jira client: %w", err)
 	}
+	return newJiraClient(client)
+}
 
+func newJiraClient(client *http.Client) (*jira.Client, error) {
 	atlassianClient := atlassian.NewClient(client)
 	resources, err := atlassianClient.AccessibleResources()
 	if err != nil {
@@ -68,6 +106,10 @@ func getJiraClient() (*jira.Client, error) {
 }
 
 func addJiraIssues(start, end time.Time) ([]*Row, error) {
+	jiraConfig, err := readJiraConfig()
+	if err != nil {
+		return nil, err
+	}
 	jiraClient, err := getJiraClient()
 	if err != nil {
 		return nil, err
@@ -79,7 +121,7 @@ func addJiraIssues(start, end time.Time) ([]*Row, error) {
 	}
 
 	issues, _, err := jiraClient.Issue.Search(
-		fmt.Sprintf("project = PD AND (assignee = currentUser() OR issuekey in updatedBy(\"%s\")) AND sprint in openSprints() ORDER BY created DESC", currentUser.DisplayName),
+		strings.ReplaceAll(jiraConfig.JQL, "{user}", currentUser.DisplayName),
 		&jira.SearchOptions{
 			Fields: []string{"*all"},
 		},
@@ -0,0 +1,109 @@
+package msgraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const DefaultBaseURL = "https://graph.microsoft.com/v1.0"
+
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewClient creates a Microsoft Graph client. If baseURL is empty
+// DefaultBaseURL is used.
+func NewClient(c *http.Client, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		httpClient: c,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+func (c *Client) Me() (*User, error) {
+	u := &User{}
+	err := c.rawRequest(http.MethodGet, c.baseURL+"/me", u)
+	return u, err
+}
+
+type CalendarViewOptions struct {
+	// CalendarID is the calendar to read, the default calendar is used if it
+	// is empty.
+	CalendarID string
+	Start      time.Time
+	End        time.Time
+}
+
+// CalendarView returns the occurrences, exceptions and single instances of
+// events between start and end. Times are returned in UTC.
+func (c *Client) CalendarView(options *CalendarViewOptions) (*PaginatedResponse[*Event], error) {
+	p := "/me/calendarView"
+	if options.CalendarID != "" {
+		p = "/me/calendars/" + url.PathEscape(options.CalendarID) + "/calendarView"
+	}
+	q := url.Values{
+		"startDateTime": {options.Start.UTC().Format(time.RFC3339)},
+		"endDateTime":   {options.End.UTC().Format(time.RFC3339)},
+		"$top":          {"100"},
+		"$orderby":      {"start/dateTime"},
+	}
+	r := &PaginatedResponse[*Event]{
+		client: c,
+	}
+	err := c.rawRequest(http.MethodGet, c.baseURL+p+"?"+q.Encode(), r)
+	return r, err
+}
+
+func (c *Client) rawRequest(method, url string, v any) error {
+	req, err := http.NewRequest(method, url, http.NoBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Prefer", `outlook.timezone="UTC"`)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		errResp := &ErrorResponse{
+			response: resp,
+		}
+		b, err := io.ReadAll(resp.Body)
+		if err == nil {
+			_ = json.Unmarshal(b, errResp)
+		}
+		return errResp
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+type ErrorResponse struct {
+	Err struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+
+	response *http.Response
+}
+
+func (e *ErrorResponse) Error() string {
+	base := "graph request failed: "
+	if e.Err.Message == "" {
+		return base + e.response.Status
+	}
+	return fmt.Sprintf("%s%s %s", base, e.Err.Code, e.Err.Message)
+}
@@ -0,0 +1,42 @@
+package msgraph
+
+import (
+	"iter"
+	"net/http"
+)
+
+// PaginatedResponse follows @odata.nextLink.
+type PaginatedResponse[T any] struct {
+	Values   []T    `json:"value"`
+	NextLink string `json:"@odata.nextLink"`
+
+	client  *Client
+	iterErr error
+}
+
+func (r *PaginatedResponse[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		page := r
+		for {
+			for _, v := range page.Values {
+				if !yield(v) {
+					return
+				}
+			}
+			if page.NextLink == "" {
+				return
+			}
+
+			next := page.NextLink
+			page = &PaginatedResponse[T]{}
+			err := r.client.rawRequest(http.MethodGet, next, page)
+			if err != nil {
+				r.iterErr = err
+				return
+			}
+		}
+	}
+}
+func (r *PaginatedResponse[T]) AllError() error {
+	return r.iterErr
+}
@@ -0,0 +1,93 @@
+package msgraph
+
+import (
+	"fmt"
+	"time"
+)
+
+type User struct {
+	ID                string `json:"id"`
+	DisplayName       string `json:"displayName"`
+	Mail              string `json:"mail"`
+	UserPrincipalName string `json:"userPrincipalName"`
+}
+
+type EmailAddress struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+type Recipient struct {
+	EmailAddress *EmailAddress `json:"emailAddress"`
+}
+
+type ResponseStatus struct {
+	// Response is one of none, organizer, tentativelyAccepted, accepted,
+	// declined or notResponded.
+	Response string    `json:"response"`
+	Time     time.Time `json:"time"`
+}
+
+type Attendee struct {
+	// Type is one of required, optional or resource.
+	Type         string          `json:"type"`
+	Status       *ResponseStatus `json:"status"`
+	EmailAddress *EmailAddress   `json:"emailAddress"`
+}
+
+// DateTimeTimeZone is a wall clock time in a named zone. Requests are made
+// with the UTC time zone preference so TimeZone is normally UTC.
+type DateTimeTimeZone struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
+}
+
+func (d *DateTimeTimeZone) Time() (time.Time, error) {
+	loc := time.UTC
+	if d.TimeZone != "" && d.TimeZone != "UTC" {
+		l, err := time.LoadLocation(d.TimeZone)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unknown time zone %q: %w", d.TimeZone, err)
+		}
+		loc = l
+	}
+	return time.ParseInLocation(dateTimeLayout, d.DateTime, loc)
+}
+
+// Date returns the wall clock time in UTC, ignoring TimeZone. All day events
+// run from midnight to midnight in every zone so only their date is used.
+func (d *DateTimeTimeZone) Date() (time.Time, error) {
+	return time.Parse(dateTimeLayout, d.DateTime)
+}
+
+const dateTimeLayout = "2006-01-02T15:04:05.9999999"
+
+type ItemBody struct {
+	ContentType string `json:"contentType"`
+	Content     string `json:"content"`
+}
+
+type OnlineMeeting struct {
+	JoinURL string `json:"joinUrl"`
+}
+
+type Event struct {
+	ID          string            `json:"id"`
+	ICalUID     string            `json:"iCalUId"`
+	Subject     string            `json:"subject"`
+	BodyPreview string            `json:"bodyPreview"`
+	Body        *ItemBody         `json:"body"`
+	Start       *DateTimeTimeZone `json:"start"`
+	End         *DateTimeTimeZone `json:"end"`
+	IsAllDay    bool              `json:"isAllDay"`
+	IsCancelled bool              `json:"isCancelled"`
+	IsOrganizer bool              `json:"isOrganizer"`
+	// ShowAs is one of free, tentative, busy, oof, workingElsewhere or
+	// unknown.
+	ShowAs         string          `json:"showAs"`
+	ResponseStatus *ResponseStatus `json:"responseStatus"`
+	Organizer      *Recipient      `json:"organizer"`
+	Attendees      []*Attendee     `json:"attendees"`
+	OnlineMeeting  *OnlineMeeting  `json:"onlineMeeting"`
+	WebLink        string          `json:"webLink"`
+}
@@ -1,6 +1,7 @@
 package open
 
 import (
+	"os"
 	"os/exec"
 	"runtime"
 	"strings"
@@ -45,3 +46,20 @@ func isWSL() bool {
 	}
 	return strings.Contains(strings.ToLower(string(releaseData)), "microsoft")
 }
+
+// CanOpen reports whether URL is likely to reach a browser the user can see.
+// It is false over SSH and in containers without a display.
+func CanOpen() bool {
+	switch runtime.GOOS {
+	case "windows", "darwin":
+		return os.Getenv("SSH_CONNECTION") == ""
+	}
+	if isWSL() {
+		return true
+	}
+	if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		return false
+	}
+	_, err := exec.LookPath("xdg-open")
+	return err == nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abibby/what-it-do/config"
+	"github.com/abibby/what-it-do/ezoauth"
+	"github.com/abibby/what-it-do/msgraph"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/calendar/v3"
+)
+
+type outlookSource struct {
+	// Tenant is the Microsoft Entra tenant id or domain, defaults to common.
+	Tenant string `json:"tenant"`
+	// CalendarID is the calendar to read, defaults to the user's default
+	// calendar.
+	CalendarID string `json:"calendar_id"`
+	// BaseURL is the Graph API root, set it to point at a stub server.
+	BaseURL string `json:"base_url"`
+	Project string `json:"project"`
+}
+
+func listOutlookEvents(source *outlookSource, start, end time.Time) ([]*calendar.Event, error) {
+	client, err := getGraphClient(source)
+	if err != nil {
+		return nil, err
+	}
+	return outlookEvents(client, source, start, end)
+}
+
+func outlookEvents(client *msgraph.Client, source *outlookSource, start, end time.Time) ([]*calendar.Event, error) {
+	me, err := client.Me()
+	if err != nil {
+		return nil, fmt.Errorf("get self: %w", err)
+	}
+
+	view, err := client.CalendarView(&msgraph.CalendarViewOptions{
+		CalendarID: source.CalendarID,
+		Start:      start,
+		End:        end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve outlook events: %w", err)
+	}
+
+	items := []*calendar.Event{}
+	for e := range view.All() {
+		item, err := graphToGoogleEvent(e, me, start.Location())
+		if err != nil {
+			return nil, err
+		}
+		if item != nil {
+			items = append(items, item)
+		}
+	}
+	if err := view.AllError(); err != nil {
+		return nil, fmt.Errorf("unable to retrieve outlook events: %w", err)
+	}
+	return items, nil
+}
+
+var graphResponses = map[string]string{
+	"organizer":           "accepted",
+	"accepted":            "accepted",
+	"tentativelyAccepted": "tentative",
+	"declined":            "declined",
+	"notResponded":        "needsAction",
+	"none":                "needsAction",
+}
+
+// graphToGoogleEvent converts a Graph event to Google's event type so it can
+// be categorised the same way as Google calendar events. Cancelled events and
+// events shown as free return nil.
+func graphToGoogleEvent(e *msgraph.Event, me *msgraph.User, loc *time.Location) (*calendar.Event, error) {
+	if e.IsCancelled || e.ShowAs == "free" {
+		return nil, nil
+	}
+	isSelf := func(email string) bool {
+		return email != "" && (strings.EqualFold(email, me.Mail) || strings.EqualFold(email, me.UserPrincipalName))
+	}
+
+	item := &calendar.Event{
+		ICalUID:     e.ICalUID,
+		Summary:     e.Subject,
+		Description: e.BodyPreview,
+		HtmlLink:    e.WebLink,
+		EventType:   "default",
+		Start:       &calendar.EventDateTime{},
+		End:         &calendar.EventDateTime{},
+	}
+	if e.ShowAs == "oof" {
+		item.EventType = "outOfOffice"
+	}
+
+	if e.Start == nil || e.End == nil {
+		return nil, fmt.Errorf("event %s has no start or end", e.ID)
+	}
+	if e.IsAllDay {
+		start, err := e.Start.Date()
+		if err != nil {
+			return nil, fmt.Errorf("invalid date format for start: %w", err)
+		}
+		end, err := e.End.Date()
+		if err != nil {
+			return nil, fmt.Errorf("invalid date format for end: %w", err)
+		}
+		item.Start.Date = start.Format(time.DateOnly)
+		item.End.Date = end.Format(time.DateOnly)
+	} else {
+		start, err := e.Start.Time()
+		if err != nil {
+			return nil, fmt.Errorf("invalid date format for start: %w", err)
+		}
+		end, err := e.End.Time()
+		if err != nil {
+			return nil, fmt.Errorf("invalid date format for end: %w", err)
+		}
+		item.Start.DateTime = start.In(loc).Format(time.RFC3339)
+		item.End.DateTime = end.In(loc).Format(time.RFC3339)
+	}
+
+	if e.OnlineMeeting != nil && e.OnlineMeeting.JoinURL != "" {
+		item.ConferenceData = &calendar.ConferenceData{
+			EntryPoints: []*calendar.EntryPoint{{EntryPointType: "video", Uri: e.OnlineMeeting.JoinURL}},
+		}
+	}
+
+	if e.Organizer != nil && e.Organizer.EmailAddress != nil {
+		item.Organizer = &calendar.EventOrganizer{
+			Email:       e.Organizer.EmailAddress.Address,
+			DisplayName: e.Organizer.EmailAddress.Name,
+			Self:        e.IsOrganizer || isSelf(e.Organizer.EmailAddress.Address),
+		}
+	}
+
+	// the event's responseStatus is the user's own response, attendee
+	// statuses can lag behind it
+	selfStatus := "needsAction"
+	if e.ResponseStatus != nil {
+		selfStatus = graphResponses[e.ResponseStatus.Response]
+	}
+	if e.IsOrganizer {
+		selfStatus = "accepted"
+	}
+	if e.ShowAs == "tentative" && selfStatus != "declined" {
+		selfStatus = "tentative"
+	}
+
+	foundSelf := false
+	for _, a := range e.Attendees {
+		if a.EmailAddress == nil {
+			continue
+		}
+		attendee := &calendar.EventAttendee{
+			Email:          a.EmailAddress.Address,
+			DisplayName:    a.EmailAddress.Name,
+			ResponseStatus: "needsAction",
+			Resource:       a.Type == "resource",
+		}
+		if a.Status != nil {
+			if status, ok := graphResponses[a.Status.Response]; ok {
+				attendee.ResponseStatus = status
+			}
+		}
+		if isSelf(attendee.Email) {
+			attendee.Self = true
+			attendee.ResponseStatus = selfStatus
+			foundSelf = true
+		}
+		item.Attendees = append(item.Attendees, attendee)
+	}
+	if !foundSelf {
+		item.Attendees = append(item.Attendees, &calendar.EventAttendee{
+			Email:          me.Mail,
+			DisplayName:    me.DisplayName,
+			ResponseStatus: selfStatus,
+			Self:           true,
+		})
+	}
+
+	return item, nil
+}
+
+func microsoftOAuthConfig(tenant string) (*ezoauth.Config, error) {
+	name := "microsoft"
+	if tenant != "" {
+		name += "_" + tenant
+	} else {
+		tenant = "common"
+	}
+
+	oauthConfig, err := ezoauth.ReadConfigJSON(config.Path("microsoft_creds.json"))
+	if err != nil {
+		return nil, err
+	}
+	oauthConfig.Endpoint = oauth2.Endpoint{
+		AuthURL:       "https://login.microsoftonline.com/" + tenant + "/oauth2/v2.0/authorize",
+		TokenURL:      "https://login.microsoftonline.com/" + tenant + "/oauth2/v2.0/token",
+		DeviceAuthURL: "https://login.microsoftonline.com/" + tenant + "/oauth2/v2.0/devicecode",
+	}
+	if len(oauthConfig.Scopes) == 0 {
+		oauthConfig.Scopes = []string{"offline_access", "User.Read", "Calendars.Read"}
+	}
+
+	return &ezoauth.Config{
+		Name:        name,
+		OAuthConfig: oauthConfig,
+		// public clients accept any port on http://localhost
+		EphemeralPort: true,
+	}, nil
+}
+
+func getGraphClient(source *outlookSource) (*msgraph.Client, error) {
+	ctx := context.Background()
+
+	ezconfig, err := microsoftOAuthConfig(source.Tenant)
+	if err != nil {
+		return nil, err
+	}
+	client, err := ezconfig.Client(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not start outlook client: %w", err)
+	}
+
+	return msgraph.NewClient(client, source.BaseURL), nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abibby/what-it-do/msgraph"
+)
+
+// graphStub serves the recorded Graph responses in testdata/msgraph with
+// links to graph.microsoft.com pointed at the stub.
+func graphStub(t *testing.T) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	serve := func(file string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Prefer") != `outlook.timezone="UTC"` {
+				t.Errorf("expected a UTC time zone preference, got %q", r.Header.Get("Prefer"))
+			}
+			b, err := os.ReadFile(path.Join("testdata", "msgraph", file))
+			if err != nil {
+				t.Error(err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(strings.ReplaceAll(string(b), msgraph.DefaultBaseURL, srv.URL+"/v1.0")))
+		}
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /v1.0/me", serve("me.json"))
+	mux.HandleFunc("GET /v1.0/me/calendarView", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("$skip") == "3" {
+			serve("calendarView-2.json")(w, r)
+			return
+		}
+		if q.Get("startDateTime") != "2024-11-12T05:00:00Z" || q.Get("endDateTime") != "2024-11-13T04:59:59Z" {
+			t.Errorf("unexpected range %s - %s", q.Get("startDateTime"), q.Get("endDateTime"))
+		}
+		serve("calendarView.json")(w, r)
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOutlookEvents(t *testing.T) {
+	srv := graphStub(t)
+	loc := time.FixedZone("EST", -5*60*60)
+	day := time.Date(2024, time.November, 12, 0, 0, 0, 0, loc)
+
+	events, err := outlookEvents(msgraph.NewClient(srv.Client(), srv.URL+"/v1.0"), &outlookSource{}, startOfDay(day), endOfDay(day))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	for _, e := range events {
+		s := fmt.Sprintf("%s %s %s%s-%s%s organizer:%s,%v", e.Summary, e.EventType, e.Start.Date, e.Start.DateTime, e.End.Date, e.End.DateTime, e.Organizer.Email, e.Organizer.Self)
+		for _, a := range e.Attendees {
+			s += fmt.Sprintf(" %s:%s", a.Email, a.ResponseStatus)
+			if a.Self {
+				s += ",self"
+			}
+			if a.Resource {
+				s += ",resource"
+			}
+		}
+		if e.ConferenceData != nil {
+			s += " video:" + e.ConferenceData.EntryPoints[0].Uri
+		}
+		got = append(got, s)
+	}
+	want := []string{
+		"Vacation outOfOffice 2024-11-12-2024-11-13 organizer:test.user@example.com,true test.user@example.com:accepted,self",
+		"PD-301: Search design review default 2024-11-12T10:00:00-05:00-2024-11-12T11:00:00-05:00 organizer:alex@example.com,false " +
+			"Test.User@example.com:accepted,self pat@acme.test:accepted room-4@example.com:accepted,resource " +
+			"video:https://teams.microsoft.com/l/meetup-join/19%3ameeting_abc",
+		"Acme weekly sync default 2024-11-12T14:00:00-05:00-2024-11-12T14:30:00-05:00 organizer:alex@example.com,false " +
+			"alex@example.com:accepted test.user@example.com:tentative,self",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d events, got %d:\n%s", len(want), len(got), strings.Join(got, "\n"))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d:\nexpected %s\n     got %s", i, want[i], got[i])
+		}
+	}
+}
+
+func TestGraphToGoogleEventInvalidDates(t *testing.T) {
+	me := &msgraph.User{Mail: "test.user@example.com"}
+	testCases := []struct {
+		name  string
+		event *msgraph.Event
+	}{
+		{"empty all day", &msgraph.Event{IsAllDay: true, Start: &msgraph.DateTimeTimeZone{}, End: &msgraph.DateTimeTimeZone{}}},
+		{"short all day", &msgraph.Event{IsAllDay: true, Start: &msgraph.DateTimeTimeZone{DateTime: "2024-11"}, End: &msgraph.DateTimeTimeZone{DateTime: "2024-11"}}},
+		{"missing end", &msgraph.Event{Start: &msgraph.DateTimeTimeZone{DateTime: "2024-11-12T15:00:00.0000000"}}},
+		{"unknown zone", &msgraph.Event{
+			Start: &msgraph.DateTimeTimeZone{DateTime: "2024-11-12T15:00:00.0000000", TimeZone: "Mars"},
+			End:   &msgraph.DateTimeTimeZone{DateTime: "2024-11-12T16:00:00.0000000", TimeZone: "Mars"},
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := graphToGoogleEvent(tc.event, me, time.UTC)
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
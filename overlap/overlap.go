@@ -0,0 +1,99 @@
+package overlap
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Policy decides which intervals are credited with time where they overlap.
+type Policy string
+
+const (
+	// FirstWins credits the interval that started first.
+	FirstWins Policy = "first-wins"
+	// Split divides the time evenly between the overlapping intervals.
+	Split Policy = "split"
+	// PreferOrganizer credits the intervals marked as Organizer, splitting
+	// evenly if there is more than one. If none are marked the time is split
+	// between all of them.
+	PreferOrganizer Policy = "prefer-organizer"
+)
+
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case FirstWins, Split, PreferOrganizer:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown overlap policy %q, expected %s, %s or %s", s, FirstWins, Split, PreferOrganizer)
+}
+
+type Interval struct {
+	Start     time.Time
+	End       time.Time
+	Organizer bool
+}
+
+// Adjust returns the duration credited to each interval so that no period of
+// time is counted twice. The sum of the result is the length of the union of
+// the intervals.
+func Adjust(intervals []Interval, policy Policy) []time.Duration {
+	result := make([]time.Duration, len(intervals))
+
+	points := []time.Time{}
+	for _, in := range intervals {
+		if !in.End.After(in.Start) {
+			continue
+		}
+		points = append(points, in.Start, in.End)
+	}
+	slices.SortFunc(points, func(a, b time.Time) int {
+		return a.Compare(b)
+	})
+	points = slices.CompactFunc(points, func(a, b time.Time) bool {
+		return a.Equal(b)
+	})
+
+	for i := 0; i+1 < len(points); i++ {
+		segStart, segEnd := points[i], points[i+1]
+
+		active := []int{}
+		for j, in := range intervals {
+			if !in.Start.After(segStart) && !in.End.Before(segEnd) && in.End.After(in.Start) {
+				active = append(active, j)
+			}
+		}
+		if len(active) == 0 {
+			continue
+		}
+
+		credited := active
+		switch policy {
+		case FirstWins:
+			first := active[0]
+			for _, j := range active[1:] {
+				if intervals[j].Start.Before(intervals[first].Start) {
+					first = j
+				}
+			}
+			credited = []int{first}
+		case PreferOrganizer:
+			organizers := []int{}
+			for _, j := range active {
+				if intervals[j].Organizer {
+					organizers = append(organizers, j)
+				}
+			}
+			if len(organizers) > 0 {
+				credited = organizers
+			}
+		}
+
+		share := segEnd.Sub(segStart) / time.Duration(len(credited))
+		for _, j := range credited {
+			result[j] += share
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,95 @@
+package overlap
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+var base = time.Date(2024, time.November, 12, 9, 0, 0, 0, time.UTC)
+
+// in returns an interval from start to end minutes after base.
+func in(start, end int) Interval {
+	return Interval{
+		Start: base.Add(time.Duration(start) * time.Minute),
+		End:   base.Add(time.Duration(end) * time.Minute),
+	}
+}
+
+// org returns an interval from start to end minutes after base marked as
+// organized by you.
+func org(start, end int) Interval {
+	i := in(start, end)
+	i.Organizer = true
+	return i
+}
+
+func TestAdjust(t *testing.T) {
+	testCases := []struct {
+		name      string
+		policy    Policy
+		intervals []Interval
+		want      []int
+	}{
+		{"empty", Split, []Interval{}, []int{}},
+		{"single", FirstWins, []Interval{in(0, 45)}, []int{45}},
+
+		{"separate first wins", FirstWins, []Interval{in(0, 30), in(60, 90)}, []int{30, 30}},
+		{"separate split", Split, []Interval{in(0, 30), in(60, 90)}, []int{30, 30}},
+		{"separate prefer organizer", PreferOrganizer, []Interval{in(0, 30), org(60, 90)}, []int{30, 30}},
+
+		{"touching first wins", FirstWins, []Interval{in(0, 30), in(30, 60)}, []int{30, 30}},
+		{"touching split", Split, []Interval{in(30, 60), in(0, 30)}, []int{30, 30}},
+		{"touching prefer organizer", PreferOrganizer, []Interval{org(0, 30), in(30, 60)}, []int{30, 30}},
+
+		{"partial first wins", FirstWins, []Interval{in(0, 60), in(30, 90)}, []int{60, 30}},
+		{"partial first wins out of order", FirstWins, []Interval{in(30, 90), in(0, 60)}, []int{30, 60}},
+		{"partial split", Split, []Interval{in(0, 60), in(30, 90)}, []int{45, 45}},
+		{"partial prefer organizer", PreferOrganizer, []Interval{in(0, 60), org(30, 90)}, []int{30, 60}},
+
+		{"identical first wins", FirstWins, []Interval{in(0, 60), in(0, 60)}, []int{60, 0}},
+		{"identical split", Split, []Interval{in(0, 60), in(0, 60), in(0, 60)}, []int{20, 20, 20}},
+		{"identical prefer organizer", PreferOrganizer, []Interval{in(0, 60), org(0, 60)}, []int{0, 60}},
+
+		{"nested first wins", FirstWins, []Interval{in(0, 120), in(30, 60)}, []int{120, 0}},
+		{"nested inner first in list", FirstWins, []Interval{in(30, 60), in(0, 120)}, []int{0, 120}},
+		{"nested split", Split, []Interval{in(0, 120), in(30, 60)}, []int{105, 15}},
+		{"nested prefer organizer", PreferOrganizer, []Interval{in(0, 120), org(30, 60)}, []int{90, 30}},
+		{"nested outer organizer", PreferOrganizer, []Interval{org(0, 120), in(30, 60)}, []int{120, 0}},
+
+		{"zero length", Split, []Interval{in(0, 60), in(30, 30)}, []int{60, 0}},
+		{"zero length alone", FirstWins, []Interval{in(30, 30)}, []int{0}},
+		{"negative length", Split, []Interval{in(0, 60), in(50, 10)}, []int{60, 0}},
+
+		{"no organizers splits", PreferOrganizer, []Interval{in(0, 60), in(30, 90)}, []int{45, 45}},
+		{"organizers split", PreferOrganizer, []Interval{org(0, 60), org(0, 60), in(0, 60)}, []int{30, 30, 0}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Adjust(tc.intervals, tc.policy)
+			minutes := make([]int, len(got))
+			for i, d := range got {
+				minutes[i] = int(d / time.Minute)
+				if d%time.Minute != 0 {
+					t.Errorf("interval %d credited %s, expected whole minutes", i, d)
+				}
+			}
+			if !slices.Equal(minutes, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, minutes)
+			}
+		})
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	for _, p := range []Policy{FirstWins, Split, PreferOrganizer} {
+		got, err := ParsePolicy(string(p))
+		if err != nil || got != p {
+			t.Errorf("expected %s, got %s %v", p, got, err)
+		}
+	}
+	_, err := ParsePolicy("last-wins")
+	if err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
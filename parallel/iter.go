@@ -10,6 +10,7 @@ func Seq[V any](ctx context.Context, s iter.Seq[V], cb func(ctx context.Context,
 	wg := &sync.WaitGroup{}
 
 	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
 	for v := range s {
 		wg.Add(1)
 		go func(v V) {
@@ -25,7 +26,7 @@ func Seq[V any](ctx context.Context, s iter.Seq[V], cb func(ctx context.Context,
 
 	wg.Wait()
 
-	return ctx.Err()
+	return context.Cause(ctx)
 }
 
 func FlatMap[V, U any](ctx context.Context, s iter.Seq[V], mapper func(ctx context.Context, s V) (iter.Seq[U], error)) iter.Seq[U] {
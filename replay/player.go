@@ -0,0 +1,103 @@
+package replay
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Player answers requests from the fixtures in a directory.
+type Player struct {
+	dir string
+
+	mtx      sync.Mutex
+	fixtures map[string]*Fixture
+	used     map[*Interaction]bool
+}
+
+func NewPlayer(dir string) *Player {
+	return &Player{
+		dir:      dir,
+		fixtures: map[string]*Fixture{},
+		used:     map[*Interaction]bool{},
+	}
+}
+
+// Transport returns a transport that answers the service's requests from
+// <dir>/<service>.json, rt is never used. It matches the signature of
+// ezoauth.WrapTransport.
+func (p *Player) Transport(service string, rt http.RoundTripper) http.RoundTripper {
+	return &playerTransport{player: p, service: service}
+}
+
+type playerTransport struct {
+	player  *Player
+	service string
+}
+
+func (t *playerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	i, err := t.player.find(t.service, req)
+	if err != nil {
+		return nil, err
+	}
+	body := i.body()
+	header := http.Header{}
+	if i.ContentType != "" {
+		header.Set("Content-Type", i.ContentType)
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.StatusCode, http.StatusText(i.StatusCode)),
+		StatusCode:    i.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// find returns the first unused interaction matching req. Once they have all
+// been used the last one is repeated.
+func (p *Player) find(service string, req *http.Request) (*Interaction, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	f, ok := p.fixtures[service]
+	if !ok {
+		var err error
+		f, err = readFixture(fixturePath(p.dir, service))
+		if errors.Is(err, os.ErrNotExist) {
+			f = &Fixture{}
+		} else if err != nil {
+			return nil, err
+		}
+		p.fixtures[service] = f
+	}
+
+	var last *Interaction
+	for _, i := range f.Interactions {
+		if i.Method != req.Method || !matchURL(i.URL, req) {
+			continue
+		}
+		if !p.used[i] {
+			p.used[i] = true
+			return i, nil
+		}
+		last = i
+	}
+	if last != nil {
+		return last, nil
+	}
+	return nil, fmt.Errorf("%w for %s %s in %s", ErrNotRecorded, req.Method, req.URL, fixturePath(p.dir, service))
+}
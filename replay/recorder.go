@@ -0,0 +1,198 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secrets in recorded urls and bodies.
+const Redacted = "REDACTED"
+
+// secretParams are query parameters that are always redacted.
+var secretParams = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"token":         true,
+	"client_secret": true,
+	"code":          true,
+	"code_verifier": true,
+	"key":           true,
+}
+
+// secretFields are json fields that are always redacted.
+var secretFields = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+	"client_secret": true,
+	"password":      true,
+}
+
+// Recorder saves the traffic of each service to <dir>/<service>.json.
+// Request headers aren't saved and secrets are redacted from urls, json
+// bodies and form encoded bodies.
+type Recorder struct {
+	dir      string
+	replacer *strings.Replacer
+
+	mtx      sync.Mutex
+	fixtures map[string]*Fixture
+}
+
+// NewRecorder records to dir. replace are old, new pairs of strings like
+// emails and account ids that are replaced in urls and bodies before they
+// are saved.
+func NewRecorder(dir string, replace ...string) *Recorder {
+	return &Recorder{
+		dir:      dir,
+		replacer: strings.NewReplacer(replace...),
+		fixtures: map[string]*Fixture{},
+	}
+}
+
+// Transport returns a transport that records the service's requests sent
+// through rt. It matches the signature of ezoauth.WrapTransport.
+func (r *Recorder) Transport(service string, rt http.RoundTripper) http.RoundTripper {
+	return &recorderTransport{recorder: r, service: service, transport: rt}
+}
+
+type recorderTransport struct {
+	recorder  *Recorder
+	service   string
+	transport http.RoundTripper
+}
+
+func (t *recorderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	err = t.recorder.record(t.service, req, resp, body)
+	if err != nil {
+		slog.Warn("Failed to record response", "url", req.URL, "err", err)
+	}
+	return resp, nil
+}
+
+func (r *Recorder) record(service string, req *http.Request, resp *http.Response, body []byte) error {
+	i := &Interaction{
+		Method:      req.Method,
+		URL:         r.replacer.Replace(scrubURL(req.URL, r.replacer)),
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	mediaType, _, _ := mime.ParseMediaType(i.ContentType)
+	// bodies are scrubbed whatever their content type, some token endpoints
+	// answer with json or a form as text/plain
+	if b, ok := scrubJSON(body); ok {
+		if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+			i.JSON = json.RawMessage(r.replacer.Replace(string(b)))
+		} else {
+			i.Body = r.replacer.Replace(string(b))
+		}
+	} else if b, ok := scrubForm(body, r.replacer); ok {
+		i.Body = r.replacer.Replace(b)
+	} else {
+		i.Body = r.replacer.Replace(string(body))
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	f, ok := r.fixtures[service]
+	if !ok {
+		f = &Fixture{Interactions: []*Interaction{}}
+		r.fixtures[service] = f
+	}
+	f.Interactions = append(f.Interactions, i)
+	return writeFixture(fixturePath(r.dir, service), f)
+}
+
+func scrubURL(u *url.URL, replacer *strings.Replacer) string {
+	s := *u
+	s.User = nil
+	query := s.Query()
+	scrubValues(query, replacer)
+	s.RawQuery = query.Encode()
+	return s.String()
+}
+
+// scrubValues redacts the secret params of query and reports if it had any.
+// The other values are replaced before they are encoded, so escaped emails
+// are replaced too.
+func scrubValues(query url.Values, replacer *strings.Replacer) bool {
+	found := false
+	for k, values := range query {
+		if secretParams[strings.ToLower(k)] || secretFields[strings.ToLower(k)] {
+			query.Set(k, Redacted)
+			found = true
+			continue
+		}
+		for i, v := range values {
+			values[i] = replacer.Replace(v)
+		}
+	}
+	return found
+}
+
+// scrubForm redacts the secret params of a form encoded body. It returns
+// false if the body isn't a form with secrets.
+func scrubForm(b []byte, replacer *strings.Replacer) (string, bool) {
+	query, err := url.ParseQuery(strings.TrimSpace(string(b)))
+	if err != nil || !scrubValues(query, replacer) {
+		return "", false
+	}
+	return query.Encode(), true
+}
+
+// scrubJSON redacts the secret fields of a json body. It returns false if the
+// body isn't json.
+func scrubJSON(b []byte) ([]byte, bool) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(b))
+	// keep large ids exactly as they were
+	dec.UseNumber()
+	err := dec.Decode(&v)
+	if err != nil || dec.More() {
+		return nil, false
+	}
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	err = enc.Encode(scrubValue(v))
+	if err != nil {
+		return nil, false
+	}
+	return bytes.TrimSpace(buf.Bytes()), true
+}
+
+func scrubValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if _, isString := child.(string); isString && secretFields[strings.ToLower(k)] {
+				v[k] = Redacted
+			} else {
+				v[k] = scrubValue(child)
+			}
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = scrubValue(child)
+		}
+	}
+	return v
+}
@@ -0,0 +1,111 @@
+package replay
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret-cookie")
+		w.Write([]byte(`{"user":"jane@example.com","access_token":"secret-token","id":12345678901234567890,"note":"<b>"}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	recorder := NewRecorder(dir, "jane@example.com", "user@example.com")
+	client := &http.Client{Transport: recorder.Transport("test", http.DefaultTransport)}
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/me?key=secret-key&b=2&a=1", http.NoBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret-header")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "secret-token") {
+		t.Errorf("the recorded response should be passed through unchanged, got %s", body)
+	}
+
+	b, err := os.ReadFile(fixturePath(dir, "test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixture := string(b)
+	for _, secret := range []string{"secret-token", "secret-key", "secret-cookie", "secret-header", "jane@example.com"} {
+		if strings.Contains(fixture, secret) {
+			t.Errorf("fixture contains %q:\n%s", secret, fixture)
+		}
+	}
+	for _, want := range []string{"user@example.com", "12345678901234567890", "<b>"} {
+		if !strings.Contains(fixture, want) {
+			t.Errorf("fixture is missing %q:\n%s", want, fixture)
+		}
+	}
+
+	player := NewPlayer(dir)
+	client = &http.Client{Transport: player.Transport("test", nil)}
+	resp, err = client.Get(srv.URL + "/me?a=1&b=2&key=" + Redacted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `"access_token":"REDACTED"`) {
+		t.Errorf("unexpected replayed body %s", body)
+	}
+
+	_, err = client.Get(srv.URL + "/other")
+	if !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("expected ErrNotRecorded, got %v", err)
+	}
+}
+
+func TestRecordScrubsBodies(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{"form", "application/x-www-form-urlencoded", "access_token=secret-token&scope=repo&token_type=bearer", "access_token=REDACTED&scope=repo&token_type=bearer"},
+		{"form as text", "text/plain; charset=utf-8", "refresh_token=secret-token&user=jane@example.com", "refresh_token=REDACTED&user=user%40example.com"},
+		{"json as text", "text/plain", `{"access_token":"secret-token","user":"jane@example.com"}`, `{"access_token":"REDACTED","user":"user@example.com"}`},
+		{"text", "text/plain", "hello jane@example.com", "hello user@example.com"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			dir := t.TempDir()
+			recorder := NewRecorder(dir, "jane@example.com", "user@example.com")
+			client := &http.Client{Transport: recorder.Transport("test", http.DefaultTransport)}
+			resp, err := client.Get(srv.URL + "/token")
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			f, err := readFixture(fixturePath(dir, "test"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(f.Interactions[0].body()); got != tc.want {
+				t.Errorf("expected body %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/abibby/what-it-do/config"
+	"github.com/abibby/what-it-do/ezoauth"
+)
+
+// settings are the top level values of a config profile.
+type settings struct {
+	// TimeZone is the IANA name of the zone used for day boundaries,
+	// defaults to the local zone.
+	TimeZone string `json:"time_zone"`
+	// JiraKeyPattern is the regular expression used to find jira keys in
+	// pull requests, commits and events. Defaults to PD-\d+.
+	JiraKeyPattern string `json:"jira_key_pattern"`
+	// TokenStore is where oauth tokens are saved, one of file (the default),
+	// encrypted or keyring.
+	TokenStore string `json:"token_store"`
+	// AuthFlow is how new oauth tokens are requested, one of auto (the
+	// default), browser, device or manual.
+	AuthFlow string `json:"auth_flow"`
+	// TokenNamespace is prefixed to the names of saved tokens so profiles
+	// signed in to different accounts don't share tokens.
+	TokenNamespace string `json:"token_namespace"`
+	// HTTPCacheTTL is how long api responses are reused, searches for days
+	// that are over are kept until the cache is cleared. Defaults to 5m.
+	HTTPCacheTTL config.Duration `json:"http_cache_ttl"`
+}
+
+func init() {
+	config.RegisterSettings(func() any { return &settings{} })
+	config.RegisterSection("calendar", func() any { return &calendarConfig{} })
+	config.RegisterSection("jira", func() any { return &jiraConfig{} })
+	config.RegisterSection("bitbucket", func() any { return &bitbucketConfig{} })
+	config.RegisterSection("bitbucket_server", func() any { return &bitbucketServerConfig{} })
+	config.RegisterSection("github", func() any { return &gitHubConfig{} })
+	config.RegisterSection("gitlab", func() any { return &gitLabConfig{} })
+	config.RegisterSection("git", func() any { return &gitConfig{} })
+}
+
+func readSettings() (*settings, error) {
+	s := &settings{}
+	err := config.Settings(s)
+	if err != nil {
+		return nil, err
+	}
+	if s.JiraKeyPattern != "" {
+		_, err = regexp.Compile(s.JiraKeyPattern)
+		if err != nil {
+			return nil, fmt.Errorf("config: invalid jira_key_pattern: %w", err)
+		}
+	}
+	switch s.TokenStore {
+	case "", tokenStoreFile, tokenStoreEncrypted, tokenStoreKeyring:
+	default:
+		return nil, fmt.Errorf("config: invalid token_store %q, must be one of %s, %s or %s", s.TokenStore, tokenStoreFile, tokenStoreEncrypted, tokenStoreKeyring)
+	}
+	if s.AuthFlow != "" {
+		_, err = ezoauth.ParseFlow(s.AuthFlow)
+		if err != nil {
+			return nil, fmt.Errorf("config: invalid auth_flow: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// loadLocation returns the named zone or the local zone if name is empty or
+// "Local".
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", name, err)
+	}
+	return loc, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abibby/what-it-do/config"
+	"github.com/abibby/what-it-do/ezoauth"
+	"github.com/abibby/what-it-do/replay"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// useFixtures answers api requests from testdata/fixtures and reads the
+// config from testdata/config.
+func useFixtures(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	config.SetDir("testdata/config")
+	err := config.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ezoauth.Offline = true
+	ezoauth.WrapTransport = replay.NewPlayer("testdata/fixtures").Transport
+	t.Cleanup(func() {
+		config.SetDir("")
+		ezoauth.Offline = false
+		ezoauth.WrapTransport = nil
+	})
+}
+
+func TestSources(t *testing.T) {
+	loc := time.FixedZone("EST", -5*60*60)
+	day := time.Date(2024, time.November, 12, 0, 0, 0, 0, loc)
+
+	tests := []struct {
+		name  string
+		fetch func(start, end time.Time) ([]*Row, error)
+		// sort rows that are built concurrently
+		sort bool
+	}{
+		{"jira", addJiraIssues, true},
+		{"bitbucket", getCodeReviews, false},
+		{"calendar", addCalenderEvents, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFixtures(t)
+
+			rows, err := tt.fetch(startOfDay(day), endOfDay(day))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.sort {
+				slices.SortFunc(rows, func(a, b *Row) int {
+					return strings.Compare(a.JiraID, b.JiraID)
+				})
+			}
+
+			got := &bytes.Buffer{}
+			err = writeRows(got, outputJSON, rows)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := path.Join("testdata", "golden", tt.name+".json")
+			if *update {
+				err = os.WriteFile(golden, got.Bytes(), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != string(want) {
+				t.Errorf("rows don't match %s, run go test -run TestSources -update if the change is expected\ngot:\n%s\nwant:\n%s", golden, got, want)
+			}
+		})
+	}
+}
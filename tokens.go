@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/abibby/what-it-do/config"
+	"github.com/abibby/what-it-do/ezoauth"
+	"golang.org/x/oauth2"
+)
+
+const (
+	tokenStoreFile      = "file"
+	tokenStoreEncrypted = "encrypted"
+	tokenStoreKeyring   = "keyring"
+
+	keyringService = "what-it-do"
+)
+
+// newTokenStore returns the oauth token store named in the config.
+func newTokenStore(name string) (ezoauth.TokenStore, error) {
+	switch name {
+	case "", tokenStoreFile:
+		return ezoauth.NewFileStore(config.TokenDir(), config.Dir()), nil
+	case tokenStoreEncrypted:
+		return ezoauth.NewEncryptedFileStore(config.TokenDir(), ezoauth.PromptPassphrase), nil
+	case tokenStoreKeyring:
+		return ezoauth.NewKeyringStore(keyringService)
+	default:
+		return nil, fmt.Errorf("unknown token store %q", name)
+	}
+}
+
+// namespacedStore prefixes token names so profiles can keep separate tokens
+// in the same store.
+type namespacedStore struct {
+	store     ezoauth.TokenStore
+	namespace string
+}
+
+var _ ezoauth.TokenStore = (*namespacedStore)(nil)
+
+func (s *namespacedStore) name(name string) string {
+	return s.namespace + "_" + name
+}
+
+func (s *namespacedStore) Load(name string) (*oauth2.Token, error) {
+	return s.store.Load(s.name(name))
+}
+
+func (s *namespacedStore) Save(name string, token *oauth2.Token) error {
+	return s.store.Save(s.name(name), token)
+}
+
+func (s *namespacedStore) Delete(name string) error {
+	return s.store.Delete(s.name(name))
+}
+
+// migrateTokens moves plaintext token files into the configured token store.
+func migrateTokens(s *settings) error {
+	if s == nil || s.TokenStore == "" || s.TokenStore == tokenStoreFile {
+		return fmt.Errorf("set token_store in the config to %s or %s before migrating", tokenStoreEncrypted, tokenStoreKeyring)
+	}
+	store, err := newTokenStore(s.TokenStore)
+	if err != nil {
+		return err
+	}
+	migrated, err := ezoauth.MigrateTokens(ezoauth.NewFileStore(config.TokenDir(), config.Dir()), store)
+	for _, name := range migrated {
+		fmt.Printf("migrated %s token to the %s store\n", name, s.TokenStore)
+	}
+	if err != nil {
+		return err
+	}
+	if len(migrated) == 0 {
+		fmt.Println("no plaintext tokens to migrate")
+	}
+	return nil
+}